@@ -1,6 +1,7 @@
 package handy
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -66,3 +67,20 @@ func BenchmarkPathWithVariables(b *testing.B) {
 		mux.ServeHTTP(w, req)
 	}
 }
+
+func BenchmarkHandle(b *testing.B) {
+	patterns := make([]string, 1000)
+	for i := range patterns {
+		patterns[i] = fmt.Sprintf("/foo/%d/bar", i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mux := NewHandy()
+		for _, p := range patterns {
+			mux.Handle(p, func() Handler {
+				return new(TestHandler)
+			})
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package handy
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// condition restricts a route to the requests that satisfy it, so the same
+// path can be served by different handlers
+type condition interface {
+	// match tells how much the request prefers the routes with the
+	// condition, from 0 (not satisfied) to 1, and if it was chosen
+	// explicitly, not by a wildcard like "Accept: */*"
+	match(r *http.Request) (quality float64, exact bool)
+	// err is reported when no route of the path satisfies the request
+	err() error
+	key() string
+}
+
+type headerCondition struct {
+	name  string
+	value string
+}
+
+// match checks if any value of the header is the expected one. Without an
+// expected value, the header only needs to be present
+func (c headerCondition) match(r *http.Request) (float64, bool) {
+	values := r.Header[c.name]
+	if c.value == "" {
+		return satisfied(len(values) > 0)
+	}
+
+	for _, v := range values {
+		if strings.TrimSpace(v) == c.value {
+			return 1, true
+		}
+	}
+
+	return 0, false
+}
+
+func (c headerCondition) err() error {
+	return ErrRouteNotFound
+}
+
+func (c headerCondition) key() string {
+	return "header " + c.name + "=" + c.value
+}
+
+type queryCondition struct {
+	name  string
+	value string
+}
+
+func (c queryCondition) match(r *http.Request) (float64, bool) {
+	values, ok := r.URL.Query()[c.name]
+	if c.value == "" {
+		return satisfied(ok)
+	}
+
+	for _, v := range values {
+		if v == c.value {
+			return 1, true
+		}
+	}
+
+	return 0, false
+}
+
+func (c queryCondition) err() error {
+	return ErrRouteNotFound
+}
+
+func (c queryCondition) key() string {
+	return "query " + c.name + "=" + c.value
+}
+
+// contentTypeCondition accepts the requests whose body has one of the media
+// types, that can be ranges like "text/*"
+type contentTypeCondition struct {
+	mediaTypes []string
+}
+
+func (c contentTypeCondition) match(r *http.Request) (float64, bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return 0, false
+	}
+
+	for _, t := range c.mediaTypes {
+		if (MediaRange{MediaType: t}).Matches(mediaType) {
+			return 1, true
+		}
+	}
+
+	return 0, false
+}
+
+func (c contentTypeCondition) err() error {
+	return ErrUnsupportedMediaType
+}
+
+func (c contentTypeCondition) key() string {
+	return "content-type " + strings.Join(c.mediaTypes, ",")
+}
+
+// acceptCondition accepts the requests that can receive one of the media
+// types, according to their Accept header, with the quality the request gives
+// to the best of them. Requests without it accept anything, but only a media
+// type listed by the request is an exact match
+type acceptCondition struct {
+	mediaTypes []string
+}
+
+func (c acceptCondition) match(r *http.Request) (float64, bool) {
+	values := r.Header["Accept"]
+	if len(values) == 0 {
+		return 1, false
+	}
+
+	return acceptQuality(ParseAccept(strings.Join(values, ",")), c.mediaTypes...)
+}
+
+func (c acceptCondition) err() error {
+	return ErrNotAcceptable
+}
+
+func (c acceptCondition) key() string {
+	return "accept " + strings.Join(c.mediaTypes, ",")
+}
+
+// acceptQuality returns the highest quality given by the Accept header to the
+// media types, and if it was given by a range that names the media type.
+// Without media types, that is, for a response of unknown type, the quality
+// is the one of "*/*"
+func acceptQuality(accept AcceptHeader, mediaTypes ...string) (float64, bool) {
+	if len(mediaTypes) == 0 {
+		return accept.Quality("*/*"), false
+	}
+
+	quality, exact := 0.0, false
+	for _, t := range mediaTypes {
+		m, ok := accept.Match(t)
+		if !ok || m.Quality <= 0 {
+			continue
+		}
+
+		isExact := m.Specificity() == 2
+		if m.Quality > quality || (m.Quality == quality && isExact) {
+			quality, exact = m.Quality, isExact
+		}
+	}
+
+	return quality, exact
+}
+
+func satisfied(ok bool) (float64, bool) {
+	if ok {
+		return 1, true
+	}
+
+	return 0, false
+}
+
+func normalizeMediaTypes(mediaTypes []string) []string {
+	normalized := make([]string, 0, len(mediaTypes))
+	for _, t := range mediaTypes {
+		normalized = append(normalized, strings.ToLower(strings.TrimSpace(t)))
+	}
+
+	return normalized
+}
+
+// conditionsKey identifies a set of conditions regardless of their order
+func conditionsKey(conditions []condition) string {
+	keys := make([]string, 0, len(conditions))
+	for _, c := range conditions {
+		keys = append(keys, c.key())
+	}
+
+	sort.Strings(keys)
+	return strings.Join(keys, "\n")
+}
+
+// worseError chooses the error that best describes why a request could not
+// be routed: an unsupported body is reported before an unacceptable
+// response, and both before a missing route
+func worseError(a, b error) error {
+	rank := func(err error) int {
+		switch err {
+		case ErrUnsupportedMediaType:
+			return 2
+		case ErrNotAcceptable:
+			return 1
+		}
+		return 0
+	}
+
+	if rank(b) > rank(a) {
+		return b
+	}
+
+	return a
+}
@@ -0,0 +1,106 @@
+package handy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouteConditions(t *testing.T) {
+	var found string
+	handler := func(name string) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+			found = name
+			return http.StatusOK
+		}
+	}
+
+	mux := NewHandy()
+	mux.HandleFunc("GET", "/users", handler("v1"), Accept("application/vnd.x.v1+json"))
+	mux.HandleFunc("GET", "/users", handler("v2"), Accept("application/vnd.x.v2+json"))
+	mux.HandleFunc("GET", "/users", handler("v2-debug"), Accept("application/vnd.x.v2+json"), Query("debug", ""))
+	mux.HandleFunc("GET", "/users", handler("beta"), Header("X-Beta", "1"))
+	mux.HandleFunc("GET", "/users", handler("default"))
+	mux.HandleFunc("POST", "/upload", handler("json"), ContentType("application/json"))
+	mux.HandleFunc("POST", "/upload", handler("text"), ContentType("text/*"))
+	mux.HandleFunc("GET", "/report", handler("csv"), Accept("text/csv"))
+
+	data := []struct {
+		method       string
+		url          string
+		header       http.Header
+		expected     string
+		expectedCode int
+	}{
+		{method: "GET", url: "/users", expected: "default", expectedCode: http.StatusOK},
+		{method: "GET", url: "/users", header: http.Header{"Accept": {"*/*"}}, expected: "default", expectedCode: http.StatusOK},
+		{method: "GET", url: "/users", header: http.Header{"Accept": {"application/vnd.x.v2+json"}}, expected: "v2", expectedCode: http.StatusOK},
+		{method: "GET", url: "/users?debug", header: http.Header{"Accept": {"application/vnd.x.v2+json"}}, expected: "v2-debug", expectedCode: http.StatusOK},
+		{method: "GET", url: "/users", header: http.Header{"Accept": {"application/json"}}, expected: "default", expectedCode: http.StatusOK},
+		{method: "GET", url: "/users", header: http.Header{"Accept": {"application/vnd.x.v1+json;q=0.1, application/vnd.x.v2+json"}}, expected: "v2", expectedCode: http.StatusOK},
+		{method: "GET", url: "/users", header: http.Header{"Accept": {"application/vnd.x.v1+json;q=0.1"}}, expected: "v1", expectedCode: http.StatusOK},
+		{method: "GET", url: "/users", header: http.Header{"Accept": {"*/*, application/vnd.x.v1+json;q=0"}}, expected: "default", expectedCode: http.StatusOK},
+		{method: "GET", url: "/users", header: http.Header{"Accept": {"application/vnd.x.v1+json;q=0.5, */*;q=0.1"}}, expected: "v1", expectedCode: http.StatusOK},
+		{method: "GET", url: "/users", header: http.Header{"Accept": {"application/json"}, "X-Beta": {"1"}}, expected: "beta", expectedCode: http.StatusOK},
+		{method: "POST", url: "/upload", header: http.Header{"Content-Type": {"application/json; charset=utf-8"}}, expected: "json", expectedCode: http.StatusOK},
+		{method: "POST", url: "/upload", header: http.Header{"Content-Type": {"text/plain"}}, expected: "text", expectedCode: http.StatusOK},
+		{method: "POST", url: "/upload", header: http.Header{"Content-Type": {"image/png"}}, expectedCode: http.StatusUnsupportedMediaType},
+		{method: "POST", url: "/upload", expectedCode: http.StatusUnsupportedMediaType},
+		{method: "GET", url: "/report", header: http.Header{"Accept": {"text/*;q=0.5, application/json"}}, expected: "csv", expectedCode: http.StatusOK},
+		{method: "GET", url: "/report", header: http.Header{"Accept": {"application/json, text/csv;q=0"}}, expectedCode: http.StatusNotAcceptable},
+		{method: "GET", url: "/report", header: http.Header{"Accept": {"*/*, text/csv;q=0"}}, expectedCode: http.StatusNotAcceptable},
+	}
+
+	for i, item := range data {
+		found = ""
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(item.method, item.url, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, values := range item.header {
+			r.Header[name] = values
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != item.expectedCode {
+			t.Errorf("Item %d: Unexpected code. Expecting %d; found %d", i, item.expectedCode, w.Code)
+		}
+
+		if found != item.expected {
+			t.Errorf("Item %d: Unexpected handler. Expecting “%s”; found “%s”", i, item.expected, found)
+		}
+	}
+}
+
+func TestRemoveRouteWithConditions(t *testing.T) {
+	router := NewRouter()
+	if err := router.AppendRoute("/users", func() Handler { return new(mockGetHandler) }, Accept("text/csv")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := router.AppendRoute("/users", func() Handler { return new(mockGetHandler) }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := router.AppendRoute("/users", func() Handler { return new(mockGetHandler) }, Accept("text/csv")); err != ErrRouteAlreadyExists {
+		t.Errorf("Unexpected error appending the same conditions. Expecting “%v”; found “%v”", ErrRouteAlreadyExists, err)
+	}
+
+	if err := router.RemoveRoute("/users", Accept("text/csv")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := router.RemoveRoute("/users", Accept("text/csv")); err != ErrRouteNotFound {
+		t.Errorf("Unexpected error removing twice. Expecting “%v”; found “%v”", ErrRouteNotFound, err)
+	}
+
+	if _, err := router.Match("/users"); err != nil {
+		t.Errorf("Route without conditions should remain; found “%v”", err)
+	}
+}
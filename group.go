@@ -0,0 +1,58 @@
+package handy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group registers routes under a common prefix, wrapping the interceptor
+// chain of their handlers with the interceptors of the group
+type Group struct {
+	handy        *Handy
+	prefix       string
+	interceptors []InterceptorFactory
+}
+
+func (handy *Handy) Group(prefix string, interceptors ...InterceptorFactory) *Group {
+	return &Group{
+		handy:        handy,
+		prefix:       strings.TrimRight(strings.TrimSpace(prefix), "/"),
+		interceptors: interceptors,
+	}
+}
+
+// Group creates a nested group; its prefix and interceptors are appended to
+// the ones of the parent group
+func (g *Group) Group(prefix string, interceptors ...InterceptorFactory) *Group {
+	chain := make([]InterceptorFactory, 0, len(g.interceptors)+len(interceptors))
+	chain = append(chain, g.interceptors...)
+
+	return &Group{
+		handy:        g.handy,
+		prefix:       strings.TrimRight(g.pattern(prefix), "/"),
+		interceptors: append(chain, interceptors...),
+	}
+}
+
+func (g *Group) Handle(pattern string, h Constructor, options ...RouteOption) {
+	options = append([]RouteOption{WithInterceptors(g.interceptors...)}, options...)
+	g.handy.Handle(g.pattern(pattern), h, options...)
+}
+
+func (g *Group) HandleFunc(method, pattern string, f HandlerFunc, options ...RouteOption) {
+	options = append([]RouteOption{WithInterceptors(g.interceptors...)}, options...)
+	g.handy.HandleFunc(method, g.pattern(pattern), f, options...)
+}
+
+// Mount serves all the requests under the prefix of the group with the
+// http.Handler, removing both prefixes from the path of the request
+func (g *Group) Mount(prefix string, h http.Handler, options ...RouteOption) {
+	options = append([]RouteOption{WithInterceptors(g.interceptors...)}, options...)
+	prefix = g.pattern(prefix)
+	g.handy.Handle(mountPattern(prefix), mountConstructor(prefix, h), options...)
+}
+
+func (g *Group) pattern(p string) string {
+	p = strings.TrimRight(strings.TrimSpace(p), "/")
+	return g.prefix + "/" + strings.TrimLeft(p, "/")
+}
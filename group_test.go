@@ -0,0 +1,133 @@
+package handy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	var calls []string
+	factory := func(name string) InterceptorFactory {
+		return func(Handler) Interceptor {
+			return &recorderInterceptor{name: name, calls: &calls}
+		}
+	}
+
+	mux := NewHandy()
+	api := mux.Group("/api/", factory("api"))
+	v1 := api.Group("v1", factory("v1"))
+
+	v1.Handle("/users/{id}", func() Handler {
+		return &mockHandler{
+			handleFunc: func() int {
+				calls = append(calls, "handler")
+				return http.StatusOK
+			},
+			interceptors: InterceptorChain{&recorderInterceptor{name: "own", calls: &calls}},
+		}
+	})
+
+	v1.HandleFunc("GET", "/ping", func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+		calls = append(calls, "func")
+		return http.StatusOK
+	})
+
+	data := []struct {
+		uri      string
+		expected []string
+	}{
+		{
+			uri: "/api/v1/users/1",
+			expected: []string{
+				"api.Before", "v1.Before", "own.Before",
+				"handler",
+				"own.After", "v1.After", "api.After",
+			},
+		},
+		{
+			uri: "/api/v1/ping",
+			expected: []string{
+				"api.Before", "v1.Before",
+				"func",
+				"v1.After", "api.After",
+			},
+		},
+	}
+
+	for i, item := range data {
+		calls = nil
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", item.uri, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if !reflect.DeepEqual(calls, item.expected) {
+			t.Errorf("Item %d: wrong calls. Expecting “%v”; found “%v”", i, item.expected, calls)
+		}
+	}
+}
+
+func TestGroupReplace(t *testing.T) {
+	var calls []string
+	mux := NewHandy()
+	api := mux.Group("/api", func(Handler) Interceptor {
+		return &recorderInterceptor{name: "api", calls: &calls}
+	})
+
+	api.Handle("/x", func() Handler {
+		return new(mockGetHandler)
+	}, Name("x"))
+
+	err := mux.Replace("/api/x", func() Handler {
+		return &mockHandler{
+			handleFunc: func() int {
+				calls = append(calls, "handler")
+				return http.StatusOK
+			},
+		}
+	})
+
+	if err != nil {
+		t.Fatal("Cannot replace a valid route", err)
+	}
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/api/x", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux.ServeHTTP(w, r)
+
+	expected := []string{"api.Before", "handler", "api.After"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Wrong calls. Expecting “%v”; found “%v”", expected, calls)
+	}
+
+	if uri, err := mux.URL("x", nil); err != nil || uri != "/api/x" {
+		t.Errorf("Wrong URL. Expecting “/api/x”; found “%s” (%v)", uri, err)
+	}
+}
+
+type recorderInterceptor struct {
+	name  string
+	calls *[]string
+}
+
+func (i *recorderInterceptor) Before() int {
+	*i.calls = append(*i.calls, i.name+".Before")
+	return 0
+}
+
+func (i *recorderInterceptor) After(status int) int {
+	*i.calls = append(*i.calls, i.name+".After")
+	return status
+}
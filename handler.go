@@ -1,6 +1,12 @@
 package handy
 
-import "net/http"
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
 
 type Handler interface {
 	Get() int
@@ -9,42 +15,64 @@ type Handler interface {
 	Delete() int
 	Patch() int
 	Head() int
+	Options() int
 	Interceptors() InterceptorChain
 	setRequestInfo(w http.ResponseWriter, r *http.Request, u URIVars)
+	setAllowedMethods(methods []string)
 }
 
+// ExtraMethodsHandler is implemented by handlers that serve HTTP methods
+// other than the ones defined in Handler, like TRACE or WebDAV's PROPFIND.
+// The returned map is indexed by the upper case HTTP method
+type ExtraMethodsHandler interface {
+	ExtraMethods() map[string]func() int
+}
+
+// HandlerFunc handles a single HTTP method of a route without the need of a
+// Handler structure
+type HandlerFunc func(http.ResponseWriter, *http.Request, URIVars) int
+
 type DefaultHandler struct {
 	NopInterceptorChain
 
-	response http.ResponseWriter
-	request  *http.Request
-	uriVars  URIVars
+	response       http.ResponseWriter
+	request        *http.Request
+	uriVars        URIVars
+	allowedMethods []string
 }
 
 func (d *DefaultHandler) Get() int {
-	return http.StatusMethodNotAllowed
+	return methodNotAllowed(d.response, d.allowedMethods)
 }
 
 func (d *DefaultHandler) Post() int {
-	return http.StatusMethodNotAllowed
+	return methodNotAllowed(d.response, d.allowedMethods)
 }
 
 func (d *DefaultHandler) Put() int {
-	return http.StatusMethodNotAllowed
+	return methodNotAllowed(d.response, d.allowedMethods)
 }
 
 func (d *DefaultHandler) Delete() int {
-	return http.StatusMethodNotAllowed
+	return methodNotAllowed(d.response, d.allowedMethods)
 }
 
 func (d *DefaultHandler) Patch() int {
-	return http.StatusMethodNotAllowed
+	return methodNotAllowed(d.response, d.allowedMethods)
 }
 
 func (d *DefaultHandler) Head() int {
-	return http.StatusMethodNotAllowed
+	return methodNotAllowed(d.response, d.allowedMethods)
 }
 
+// Options answers with the methods that the route actually serves
+func (d *DefaultHandler) Options() int {
+	d.response.Header().Set("Allow", strings.Join(d.allowedMethods, ", "))
+	return http.StatusOK
+}
+
+// ResponseWriter returns the writer of the response, that is a
+// *StatusResponseWriter
 func (d *DefaultHandler) ResponseWriter() http.ResponseWriter {
 	return d.response
 }
@@ -58,5 +86,111 @@ func (d *DefaultHandler) URIVars() URIVars {
 }
 
 func (d *DefaultHandler) setRequestInfo(w http.ResponseWriter, r *http.Request, u URIVars) {
-	*d = DefaultHandler{response: w, request: r, uriVars: u}
+	if w != nil {
+		w = NewStatusResponseWriter(w)
+	}
+
+	d.response, d.request, d.uriVars = w, r, u
+}
+
+func (d *DefaultHandler) setAllowedMethods(methods []string) {
+	d.allowedMethods = methods
+}
+
+// methodNotAllowed answers with 405, setting the Allow header required by
+// RFC 7231. The response is sent here, as the routes may have no interceptor
+// that writes the returned status
+func methodNotAllowed(w http.ResponseWriter, methods []string) int {
+	if w != nil {
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+
+	return http.StatusMethodNotAllowed
+}
+
+var (
+	defaultHandlerType = reflect.TypeOf(DefaultHandler{})
+
+	// standardMethods are the HTTP methods served by the Handler methods
+	standardMethods = []string{"GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS"}
+)
+
+// handlerMethods lists the HTTP methods that the handler built by the
+// constructor serves by itself, that is, the DefaultHandler methods that it
+// overrides followed by its extra methods
+func handlerMethods(c Constructor) []string {
+	h := c()
+	t := reflect.TypeOf(h)
+
+	var methods []string
+	for _, method := range standardMethods {
+		name := method[:1] + strings.ToLower(method[1:])
+		if d := declaringType(t, name); d != nil && d != defaultHandlerType {
+			methods = append(methods, method)
+		}
+	}
+
+	if eh, ok := h.(ExtraMethodsHandler); ok {
+		var extra []string
+		for method := range eh.ExtraMethods() {
+			extra = append(extra, method)
+		}
+
+		sort.Strings(extra)
+		methods = append(methods, extra...)
+	}
+
+	return methods
+}
+
+// declaringType looks for the type that declares the method, following the
+// embedded fields the same way the method would be promoted.
+//
+// The reflect package lists promoted methods as if the type declared them, so
+// they are told apart by isPromoted, that relies on the compiler reporting
+// "<autogenerated>" as the file of the wrappers it generates for them. No API
+// promises it, but it holds with -trimpath, -N -l and -s -w. If it ever
+// changes, the methods promoted from DefaultHandler would be taken for the
+// handler's own ones, being listed in the Allow header
+func declaringType(t reflect.Type, name string) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, typ := range []reflect.Type{t, reflect.PtrTo(t)} {
+		if m, ok := typ.MethodByName(name); ok && !isPromoted(m) {
+			return t
+		}
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Anonymous {
+			if d := declaringType(field.Type, name); d != nil {
+				return d
+			}
+		}
+	}
+
+	return nil
+}
+
+// isPromoted detects the wrappers that the compiler generates for methods
+// promoted from embedded fields
+func isPromoted(m reflect.Method) bool {
+	if !m.Func.IsValid() {
+		return false
+	}
+
+	f := runtime.FuncForPC(m.Func.Pointer())
+	if f == nil {
+		return false
+	}
+
+	file, _ := f.FileLine(f.Entry())
+	return file == "<autogenerated>"
 }
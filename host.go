@@ -0,0 +1,114 @@
+package handy
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hostRoute holds the routes that only match requests to a host (eg:
+// "{tenant}.example.com"), optionally also restricted to a scheme (eg:
+// "https://api.example.com")
+type hostRoute struct {
+	pattern string
+	scheme  string
+	labels  []*node
+	vars    []string
+	root    *node
+}
+
+func newHostRoute(pattern string) (*hostRoute, error) {
+	h := &hostRoute{pattern: pattern, root: newNode("", nil)}
+
+	if i := strings.Index(pattern, "://"); i >= 0 {
+		h.scheme, pattern = strings.ToLower(pattern[:i]), pattern[i+3:]
+	}
+
+	for _, label := range strings.Split(pattern, ".") {
+		if label == "" {
+			return nil, ErrInvalidHost
+		}
+
+		if !isWildcard(label) {
+			h.labels = append(h.labels, newNode(strings.ToLower(label), nil))
+			continue
+		}
+
+		name, _, catchAll := parseWildcard(label)
+		if catchAll {
+			return nil, ErrInvalidHost
+		}
+
+		n, err := newWildcard(label, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		h.labels = append(h.labels, n)
+		h.vars = append(h.vars, name)
+	}
+
+	return h, nil
+}
+
+// match checks the scheme and the host of the request, returning the values
+// of the wildcards of the host
+func (h *hostRoute) match(scheme, host string) ([]string, bool) {
+	if h.scheme != "" && h.scheme != scheme {
+		return nil, false
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) != len(h.labels) {
+		return nil, false
+	}
+
+	var values []string
+	for i, l := range h.labels {
+		if !l.isWildcard {
+			if !strings.EqualFold(l.name, labels[i]) {
+				return nil, false
+			}
+
+			continue
+		}
+
+		if !l.accepts(labels[i]) {
+			return nil, false
+		}
+
+		values = append(values, labels[i])
+	}
+
+	return values, true
+}
+
+// specificity is used to try the hosts with more static labels first
+func (h *hostRoute) specificity() int {
+	s := len(h.labels) - len(h.vars)
+	if h.scheme != "" {
+		s++
+	}
+
+	return s
+}
+
+// requestHost finds out the scheme and the host, without port, of the
+// request
+func requestHost(r *http.Request) (scheme, host string) {
+	scheme = "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	if r.URL.Scheme != "" {
+		scheme = strings.ToLower(r.URL.Scheme)
+	}
+
+	host = r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return scheme, strings.TrimSuffix(host, ".")
+}
@@ -0,0 +1,98 @@
+package handy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHandleHost(t *testing.T) {
+	var found string
+	var vars URIVars
+	handler := func(name string) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+			found, vars = name, u
+			return http.StatusOK
+		}
+	}
+
+	mux := NewHandy()
+	mux.HandleFunc("GET", "/users/{id}", handler("tenant"), Host("{tenant}.example.com"))
+	mux.HandleFunc("GET", "/users/{id}", handler("admin"), Host("admin.example.com"))
+	mux.HandleFunc("GET", "/users/{id}", handler("secure"), Host("https://secure.example.com"))
+	mux.HandleFunc("GET", "/users/{id}", handler("default"))
+	mux.HandleFunc("GET", "/other", handler("other"))
+
+	data := []struct {
+		url          string
+		tls          bool
+		expected     string
+		expectedVars URIVars
+	}{
+		{url: "http://acme.example.com/users/1", expected: "tenant", expectedVars: URIVars{"tenant": "acme", "id": "1"}},
+		{url: "http://ACME.Example.com:8080/users/1", expected: "tenant", expectedVars: URIVars{"tenant": "ACME", "id": "1"}},
+		{url: "http://admin.example.com/users/1", expected: "admin", expectedVars: URIVars{"id": "1"}},
+		{url: "http://secure.example.com/users/1", expected: "tenant", expectedVars: URIVars{"tenant": "secure", "id": "1"}},
+		{url: "http://secure.example.com/users/1", tls: true, expected: "secure", expectedVars: URIVars{"id": "1"}},
+		{url: "http://example.com/users/1", expected: "default", expectedVars: URIVars{"id": "1"}},
+		{url: "http://acme.example.com/other", expected: "other", expectedVars: URIVars{}},
+	}
+
+	for i, item := range data {
+		found, vars = "", nil
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", item.url, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Requests received by a server don't have the scheme in the URL
+		r.URL.Scheme = ""
+		if item.tls {
+			r.TLS = new(tls.ConnectionState)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if found != item.expected {
+			t.Errorf("Item %d: wrong route for “%s”. Expecting “%s”; found “%s”", i, item.url, item.expected, found)
+		}
+
+		if !reflect.DeepEqual(vars, item.expectedVars) {
+			t.Errorf("Item %d: wrong URI variables. Expecting “%v”; found “%v”", i, item.expectedVars, vars)
+		}
+	}
+
+	expected := []string{
+		"https://secure.example.com/users/{id}",
+		"admin.example.com/users/{id}",
+		"{tenant}.example.com/users/{id}",
+		"/other",
+		"/users/{id}",
+	}
+
+	var patterns []string
+	for _, route := range mux.Routes() {
+		patterns = append(patterns, route.Pattern)
+	}
+
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Errorf("Wrong routes. Expecting “%v”; found “%v”", expected, patterns)
+	}
+
+	if err := mux.Remove("/users/{id}", Host("admin.example.com")); err != nil {
+		t.Fatal("Cannot remove a valid route", err)
+	}
+
+	if routes := mux.Routes(); len(routes) != len(expected)-1 {
+		t.Errorf("Wrong number of routes after removal: %v", routes)
+	}
+
+	if err := NewRouter().AppendRoute("/x", func() Handler { return new(DefaultHandler) }, Host("a..b")); err != ErrInvalidHost {
+		t.Error("Appending a route with an invalid host", err)
+	}
+}
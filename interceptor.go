@@ -1,12 +1,28 @@
 package handy
 
+import "net/http"
+
 type Interceptor interface {
 	Before() int
 	After(int) int
 }
 
+// WrapperInterceptor is implemented by interceptors that need to run around
+// the rest of the chain, like the standard func(http.Handler) http.Handler
+// middlewares. Wrap is called after Before and must call next at most once,
+// with the ResponseWriter and the request that the handler will use, returning
+// the status of the response
+type WrapperInterceptor interface {
+	Interceptor
+	Wrap(w http.ResponseWriter, r *http.Request, next func(http.ResponseWriter, *http.Request) int) int
+}
+
 type InterceptorChain []Interceptor
 
+// InterceptorFactory builds an interceptor for the handler of each request,
+// allowing interceptors to be registered outside of the handler
+type InterceptorFactory func(Handler) Interceptor
+
 func (c InterceptorChain) Chain(f Interceptor) InterceptorChain {
 	return append(c, f)
 }
@@ -0,0 +1,260 @@
+package interceptor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/trajber/handy"
+)
+
+// Codec decodes the request fields and encodes the response fields of the
+// handlers in a media type
+type Codec interface {
+	// MediaTypes lists the media types of the codec; the first one is sent
+	// in the Content-Type of the response when the client accepts anything
+	MediaTypes() []string
+	Decode(r io.Reader, v interface{}) error
+	Encode(w io.Writer, v interface{}) error
+}
+
+// DefaultCodecs are the codecs used by the Negotiator when none is given,
+// in order of preference. New codecs can be registered by appending them
+var DefaultCodecs = []Codec{JSON, XML}
+
+// JSON is the codec of the "application/json" media type
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaTypes() []string {
+	return []string{"application/json"}
+}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	for {
+		if err := decoder.Decode(v); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+type codecHandler interface {
+	Field(string, string) interface{}
+	Req() *http.Request
+	ResponseWriter() http.ResponseWriter
+}
+
+// writtenChecker is implemented by the ResponseWriters that know if the
+// response was already sent, like handy.StatusResponseWriter
+type writtenChecker interface {
+	Written() bool
+}
+
+// codecInterceptor decodes the request field and encodes the response field
+// of the handler with a single codec. Bodies that cannot be decoded are
+// answered with decodeStatus
+type codecInterceptor struct {
+	handler      codecHandler
+	codec        Codec
+	decodeStatus int
+}
+
+func (c *codecInterceptor) Before() int {
+	r := c.handler.Req()
+	requestField := c.handler.Field("request", strings.ToLower(r.Method))
+
+	if requestField == nil || r.Body == nil {
+		return 0
+	}
+
+	if err := c.codec.Decode(r.Body, requestField); err != nil {
+		return c.decodeStatus
+	}
+
+	return 0
+}
+
+func (c *codecInterceptor) After(status int) int {
+	return writeResponse(c.handler, c.codec, c.codec.MediaTypes()[0], status)
+}
+
+// writeResponse sends the response field of the handler encoded by the codec,
+// adding the headers of its header field. Without a codec or a response
+// field only the status is sent, and nothing is sent if the handler answered
+// the request by itself
+func writeResponse(h codecHandler, codec Codec, mediaType string, status int) int {
+	w := h.ResponseWriter()
+	if checker, ok := w.(writtenChecker); ok && checker.Written() {
+		return status
+	}
+
+	if header, ok := h.Field("response", "header").(*http.Header); ok {
+		for k, values := range *header {
+			for _, value := range values {
+				w.Header().Add(k, value)
+			}
+		}
+	}
+
+	response := responseField(h, strings.ToLower(h.Req().Method))
+	if response == nil || codec == nil {
+		w.WriteHeader(status)
+		return status
+	}
+
+	var buf bytes.Buffer
+	if err := codec.Encode(&buf, response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+
+	return status
+}
+
+// responseField returns the field sent in the responses to the method
+func responseField(h codecHandler, method string) interface{} {
+	if response := h.Field("response", "all"); response != nil {
+		return response
+	}
+
+	return h.Field("response", method)
+}
+
+// Negotiator decodes the request field with the codec of the request's
+// Content-Type and encodes the response field with the codec that best fits
+// its Accept header, using the same tags as JSONCodec. Requests are answered
+// with 415 (Unsupported Media Type) or 406 (Not Acceptable) when no codec
+// fits
+type Negotiator struct {
+	handler   codecHandler
+	codecs    []Codec
+	encoder   Codec
+	mediaType string
+	failed    bool
+}
+
+func NewNegotiator(h codecHandler, codecs ...Codec) *Negotiator {
+	if len(codecs) == 0 {
+		codecs = DefaultCodecs
+	}
+
+	return &Negotiator{handler: h, codecs: codecs}
+}
+
+func (n *Negotiator) Before() int {
+	status := n.before()
+	n.failed = status != 0
+	return status
+}
+
+func (n *Negotiator) before() int {
+	r := n.handler.Req()
+	method := strings.ToLower(r.Method)
+
+	if responseField(n.handler, method) != nil {
+		n.encoder, n.mediaType = n.negotiate(r.Header.Get("Accept"))
+		if n.encoder == nil {
+			return http.StatusNotAcceptable
+		}
+	}
+
+	requestField := n.handler.Field("request", method)
+	if requestField == nil || r.Body == nil {
+		return 0
+	}
+
+	decoder := n.codecs[0]
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		if decoder = n.decoder(contentType); decoder == nil {
+			return http.StatusUnsupportedMediaType
+		}
+	}
+
+	if err := decoder.Decode(r.Body, requestField); err != nil {
+		return http.StatusBadRequest
+	}
+
+	return 0
+}
+
+func (n *Negotiator) After(status int) int {
+	if n.failed {
+		return writeResponse(n.handler, nil, "", status)
+	}
+
+	return writeResponse(n.handler, n.encoder, n.mediaType, status)
+}
+
+// decoder returns the codec of the media type of the request body
+func (n *Negotiator) decoder(contentType string) Codec {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	for _, codec := range n.codecs {
+		for _, t := range codec.MediaTypes() {
+			if t == mediaType {
+				return codec
+			}
+		}
+	}
+
+	return nil
+}
+
+// negotiate chooses the codec of the response and its media type: the one
+// with the highest quality in the Accept header, preferring the media types
+// named by it to the ones matched by wildcards and, between equals, the order
+// of the codecs. Without the header, the first codec is used
+func (n *Negotiator) negotiate(accept string) (Codec, string) {
+	if strings.TrimSpace(accept) == "" {
+		return n.codecs[0], n.codecs[0].MediaTypes()[0]
+	}
+
+	ranges := handy.ParseAccept(accept)
+
+	var best Codec
+	var bestType string
+	var bestRange handy.MediaRange
+	for _, codec := range n.codecs {
+		for _, t := range codec.MediaTypes() {
+			m, ok := ranges.Match(t)
+			if !ok || m.Quality <= 0 {
+				continue
+			}
+
+			if best == nil || m.Quality > bestRange.Quality ||
+				(m.Quality == bestRange.Quality && m.Specificity() > bestRange.Specificity()) {
+				best, bestType, bestRange = codec, t, m
+			}
+		}
+	}
+
+	return best, bestType
+}
@@ -0,0 +1,161 @@
+package interceptor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/trajber/handy"
+)
+
+// textCodec encodes and decodes plain strings
+type textCodec struct{}
+
+func (textCodec) MediaTypes() []string {
+	return []string{"text/plain"}
+}
+
+func (textCodec) Decode(r io.Reader, v interface{}) error {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return err
+	}
+
+	*v.(*string) = buf.String()
+	return nil
+}
+
+func (textCodec) Encode(w io.Writer, v interface{}) error {
+	_, err := io.WriteString(w, *v.(*string))
+	return err
+}
+
+type mockNegotiatorHandler struct {
+	handy.DefaultHandler
+	IntrospectorCompliant
+
+	Request  string `request:"post"`
+	Response string `response:"post"`
+}
+
+func (m *mockNegotiatorHandler) Post() int {
+	m.Response = "echo " + m.Request
+	return http.StatusOK
+}
+
+func (m *mockNegotiatorHandler) Interceptors() handy.InterceptorChain {
+	return handy.NewInterceptorChain().
+		Chain(NewIntrospector(m)).
+		Chain(NewNegotiator(m, JSON, textCodec{}))
+}
+
+func TestNegotiator(t *testing.T) {
+	mux := handy.NewHandy()
+	mux.Handle("/echo", func() handy.Handler {
+		return new(mockNegotiatorHandler)
+	})
+
+	data := []struct {
+		contentType         string
+		accept              string
+		body                string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			contentType:         "application/json",
+			body:                `"hello"`,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `"echo hello"`,
+		},
+		{
+			contentType:         "text/plain; charset=utf-8",
+			accept:              "application/json;q=0.5, text/plain",
+			body:                "hello",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain",
+			expectedBody:        "echo hello",
+		},
+		{
+			body:                `"hello"`,
+			accept:              "*/*, text/plain",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain",
+			expectedBody:        "echo hello",
+		},
+		{
+			body:                `"hello"`,
+			accept:              "application/*;q=0.5, text/*;q=0.8",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain",
+			expectedBody:        "echo hello",
+		},
+		{
+			body:                `"hello"`,
+			accept:              "application/json;q=0, */*",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain",
+			expectedBody:        "echo hello",
+		},
+		{
+			contentType:    "application/xml",
+			body:           "<hello/>",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			contentType:    "application/json",
+			accept:         "image/png",
+			body:           `"hello"`,
+			expectedStatus: http.StatusNotAcceptable,
+		},
+		{
+			contentType:    "application/json",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i, item := range data {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("POST", "/echo", strings.NewReader(item.body))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.contentType != "" {
+			r.Header.Set("Content-Type", item.contentType)
+		}
+
+		if item.accept != "" {
+			r.Header.Set("Accept", item.accept)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != item.expectedStatus {
+			t.Errorf("Item %d: Wrong status code. Expecting “%d”; found “%d”", i, item.expectedStatus, w.Code)
+		}
+
+		if item.expectedStatus != http.StatusOK {
+			if w.Body.Len() > 0 {
+				t.Errorf("Item %d: Unexpected response “%s”", i, w.Body.String())
+			}
+
+			continue
+		}
+
+		if contentType := w.Header().Get("Content-Type"); contentType != item.expectedContentType {
+			t.Errorf("Item %d: Wrong content type. Expecting “%s”; found “%s”", i, item.expectedContentType, contentType)
+		}
+
+		if w.Body.String() != item.expectedBody {
+			t.Errorf("Item %d: Wrong response. Expecting “%s”; found “%s”", i, item.expectedBody, w.Body.String())
+		}
+	}
+}
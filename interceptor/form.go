@@ -0,0 +1,150 @@
+package interceptor
+
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+)
+
+var (
+	// DefaultFormMaxMemory is the size of a multipart body kept in memory;
+	// the rest of the files is stored in temporary files
+	DefaultFormMaxMemory int64 = 32 << 20 // 32 MB
+
+	// DefaultFormMaxBodySize limits the size of the whole body
+	DefaultFormMaxBodySize int64 = 64 << 20 // 64 MB
+)
+
+type formHandler interface {
+	KeysWithTag(string) []string
+	Field(string, string) interface{}
+	SetField(string, string, interface{})
+	Req() *http.Request
+}
+
+// Form binds the fields of "application/x-www-form-urlencoded" and
+// "multipart/form-data" bodies to the handler fields with the "form" tag.
+// Uploaded files are bound to *multipart.FileHeader or
+// []*multipart.FileHeader fields.
+//
+// The limits are enforced while the body is read, so Form must come before
+// any interceptor that parses the form, like QueryString. When the form was
+// already parsed, only the declared Content-Length and the size of the files
+// can be checked
+type Form struct {
+	NopInterceptor
+
+	// MaxMemory is the size of a multipart body kept in memory
+	MaxMemory int64
+
+	// MaxBodySize limits the size of the body; zero means no limit
+	MaxBodySize int64
+
+	// MaxFileSize limits the size of each uploaded file; zero means no limit.
+	// Files are checked once the whole body was read, so the space they take
+	// in temporary files is limited by MaxBodySize
+	MaxFileSize int64
+
+	handler formHandler
+}
+
+func NewForm(h formHandler) *Form {
+	return &Form{
+		MaxMemory:   DefaultFormMaxMemory,
+		MaxBodySize: DefaultFormMaxBodySize,
+		handler:     h,
+	}
+}
+
+func (f *Form) Before() int {
+	r := f.handler.Req()
+	if r.Body == nil {
+		return 0
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return 0
+	}
+
+	if mediaType != "application/x-www-form-urlencoded" && mediaType != "multipart/form-data" {
+		return 0
+	}
+
+	// ParseForm doesn't read multipart bodies, only ParseMultipartForm does
+	parsed := r.MultipartForm != nil
+	if mediaType == "application/x-www-form-urlencoded" {
+		parsed = r.PostForm != nil
+	}
+
+	if parsed {
+		// The body was already read without the limits
+		if f.MaxBodySize > 0 && r.ContentLength > f.MaxBodySize {
+			return http.StatusRequestEntityTooLarge
+		}
+	} else {
+		err = f.parse(r, mediaType)
+	}
+
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return http.StatusRequestEntityTooLarge
+		}
+
+		return http.StatusBadRequest
+	}
+
+	for _, key := range f.handler.KeysWithTag("form") {
+		if files := f.files(key); files != nil {
+			if status := f.setFiles(key, files); status != 0 {
+				return status
+			}
+		}
+	}
+
+	if err := bindValues(f.handler, "form", r.PostForm); err != nil {
+		return http.StatusBadRequest
+	}
+
+	return 0
+}
+
+func (f *Form) parse(r *http.Request, mediaType string) error {
+	if f.MaxBodySize > 0 {
+		r.Body = http.MaxBytesReader(nil, r.Body, f.MaxBodySize)
+	}
+
+	if mediaType == "multipart/form-data" {
+		return r.ParseMultipartForm(f.MaxMemory)
+	}
+
+	return r.ParseForm()
+}
+
+func (f *Form) files(key string) []*multipart.FileHeader {
+	if form := f.handler.Req().MultipartForm; form != nil {
+		return form.File[key]
+	}
+
+	return nil
+}
+
+func (f *Form) setFiles(key string, files []*multipart.FileHeader) int {
+	if f.MaxFileSize > 0 {
+		for _, file := range files {
+			if file.Size > f.MaxFileSize {
+				return http.StatusRequestEntityTooLarge
+			}
+		}
+	}
+
+	if field, ok := f.handler.Field("form", key).(*[]*multipart.FileHeader); ok {
+		*field = files
+		return 0
+	}
+
+	f.handler.SetField("form", key, files[0])
+	return 0
+}
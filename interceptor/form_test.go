@@ -0,0 +1,204 @@
+package interceptor
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/trajber/handy"
+)
+
+type mockFormHandler struct {
+	handy.DefaultHandler
+	IntrospectorCompliant
+
+	request *http.Request
+
+	Name   string                  `form:"name"`
+	Age    int                     `form:"age"`
+	Avatar *multipart.FileHeader   `form:"avatar"`
+	Photos []*multipart.FileHeader `form:"photos"`
+}
+
+func (m *mockFormHandler) Req() *http.Request {
+	return m.request
+}
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string][]string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+
+	for name, contents := range files {
+		for i, content := range contents {
+			part, err := writer.CreateFormFile(name, name+string(rune('a'+i))+".txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			part.Write([]byte(content))
+		}
+	}
+
+	writer.Close()
+
+	r, err := http.NewRequest("POST", "/", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+func TestFormBefore(t *testing.T) {
+	urlencoded, err := http.NewRequest("POST", "/?name=query", strings.NewReader("name=John&age=42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urlencoded.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	invalid, err := http.NewRequest("POST", "/", strings.NewReader("age=old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	data := []struct {
+		description    string
+		request        *http.Request
+		maxFileSize    int64
+		maxBodySize    int64
+		expectedStatus int
+		expectedName   string
+		expectedAge    int
+		expectedAvatar string
+		expectedPhotos int
+	}{
+		{
+			description:  "URL encoded form",
+			request:      urlencoded,
+			expectedName: "John",
+			expectedAge:  42,
+		},
+		{
+			description: "multipart form with files",
+			request: newMultipartRequest(t,
+				map[string]string{"name": "Mary"},
+				map[string][]string{"avatar": {"me"}, "photos": {"one", "two"}},
+			),
+			expectedName:   "Mary",
+			expectedAvatar: "avatara.txt",
+			expectedPhotos: 2,
+		},
+		{
+			description:    "invalid value",
+			request:        invalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description: "file too large",
+			request: newMultipartRequest(t, nil,
+				map[string][]string{"photos": {"small", "too large"}},
+			),
+			maxFileSize:    5,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			description: "body too large",
+			request: newMultipartRequest(t, nil,
+				map[string][]string{"avatar": {strings.Repeat("x", 1024)}},
+			),
+			maxBodySize:    512,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for i, item := range data {
+		handler := &mockFormHandler{request: item.request}
+		NewIntrospector(handler).Before()
+
+		form := NewForm(handler)
+		form.MaxFileSize = item.maxFileSize
+		if item.maxBodySize > 0 {
+			form.MaxBodySize = item.maxBodySize
+		}
+
+		if status := form.Before(); status != item.expectedStatus {
+			t.Errorf("Item %d, “%s”: Wrong status code. Expecting “%d”; found “%d”", i, item.description, item.expectedStatus, status)
+			continue
+		}
+
+		if item.expectedStatus != 0 {
+			continue
+		}
+
+		if handler.Name != item.expectedName || handler.Age != item.expectedAge {
+			t.Errorf("Item %d, “%s”: Wrong values. Expecting “%s” and “%d”; found “%s” and “%d”", i, item.description, item.expectedName, item.expectedAge, handler.Name, handler.Age)
+		}
+
+		avatar := ""
+		if handler.Avatar != nil {
+			avatar = handler.Avatar.Filename
+		}
+
+		if avatar != item.expectedAvatar {
+			t.Errorf("Item %d, “%s”: Wrong avatar. Expecting “%s”; found “%s”", i, item.description, item.expectedAvatar, avatar)
+		}
+
+		if len(handler.Photos) != item.expectedPhotos {
+			t.Errorf("Item %d, “%s”: Wrong number of photos. Expecting %d; found %d", i, item.description, item.expectedPhotos, len(handler.Photos))
+		}
+	}
+}
+
+func TestFormBeforeAfterQueryString(t *testing.T) {
+	data := []struct {
+		description    string
+		maxBodySize    int64
+		maxFileSize    int64
+		expectedStatus int
+	}{
+		{
+			description: "form already parsed",
+		},
+		{
+			description:    "file too large",
+			maxFileSize:    3,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			description:    "body too large",
+			maxBodySize:    10,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for i, item := range data {
+		r := newMultipartRequest(t, map[string]string{"name": "John"}, map[string][]string{"avatar": {"avatar"}})
+		handler := &mockFormHandler{request: r}
+		NewIntrospector(handler).Before()
+
+		if status := NewQueryString(handler).Before(); status != 0 {
+			t.Fatalf("Item %d, “%s”: Cannot parse the query string; status %d", i, item.description, status)
+		}
+
+		form := NewForm(handler)
+		form.MaxBodySize = item.maxBodySize
+		form.MaxFileSize = item.maxFileSize
+
+		if status := form.Before(); status != item.expectedStatus {
+			t.Errorf("Item %d, “%s”: Wrong status code. Expecting “%d”; found “%d”", i, item.description, item.expectedStatus, status)
+		}
+
+		if item.expectedStatus == 0 && (handler.Name != "John" || handler.Avatar == nil) {
+			t.Errorf("Item %d, “%s”: Form not bound: “%s”, %v", i, item.description, handler.Name, handler.Avatar)
+		}
+	}
+}
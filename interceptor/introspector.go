@@ -14,6 +14,13 @@ type setFielder interface {
 	SetFields(StructFields)
 }
 
+// fieldLayouts holds the layout tag of the fields, indexed like StructFields
+type fieldLayouts map[string]map[string]string
+
+type setLayouter interface {
+	setLayouts(fieldLayouts)
+}
+
 type Introspector struct {
 	NopInterceptor
 
@@ -27,20 +34,24 @@ func NewIntrospector(st setFielder) *Introspector {
 func (i *Introspector) Before() int {
 	st := reflect.ValueOf(i.structure).Elem()
 	fields := make(StructFields)
+	layouts := make(fieldLayouts)
 
-	i.parse(st, fields)
+	i.parse(st, fields, layouts)
 	i.structure.SetFields(fields)
+	if l, ok := i.structure.(setLayouter); ok {
+		l.setLayouts(layouts)
+	}
 	return 0
 }
 
-func (i *Introspector) parse(st reflect.Value, fields StructFields) {
+func (i *Introspector) parse(st reflect.Value, fields StructFields, layouts fieldLayouts) {
 	typ := st.Type()
 
 	for j := 0; j < st.NumField(); j++ {
 		field := typ.Field(j)
 
 		if field.Type.Kind() == reflect.Struct && field.Anonymous {
-			i.parse(st.Field(j), fields)
+			i.parse(st.Field(j), fields, layouts)
 			continue
 		}
 
@@ -48,11 +59,18 @@ func (i *Introspector) parse(st reflect.Value, fields StructFields) {
 			continue
 		}
 
+		// The layout is read as a whole, as literal layouts can have spaces
+		// and commas (eg: `layout:"Mon, 02 Jan 2006"`)
+		layout := field.Tag.Get("layout")
+
 		for _, ts := range strings.Split(string(field.Tag), " ") {
 			tags := tagFormat.FindAllStringSubmatch(ts, -1)
 
 			for _, tagParts := range tags {
 				name, values := tagParts[1], tagParts[2]
+				if name == "layout" {
+					continue
+				}
 
 				for _, value := range strings.Split(values, ",") {
 					if _, ok := fields[name]; !ok {
@@ -60,6 +78,14 @@ func (i *Introspector) parse(st reflect.Value, fields StructFields) {
 					}
 
 					fields[name][value] = st.Field(j)
+
+					if layout != "" {
+						if _, ok := layouts[name]; !ok {
+							layouts[name] = make(map[string]string)
+						}
+
+						layouts[name][value] = layout
+					}
 				}
 			}
 		}
@@ -83,13 +109,18 @@ func emptyInterface(v reflect.Value) interface{} {
 }
 
 type IntrospectorCompliant struct {
-	fields StructFields
+	fields  StructFields
+	layouts fieldLayouts
 }
 
 func (i *IntrospectorCompliant) SetFields(fields StructFields) {
 	i.fields = fields
 }
 
+func (i *IntrospectorCompliant) setLayouts(layouts fieldLayouts) {
+	i.layouts = layouts
+}
+
 func (i *IntrospectorCompliant) SetField(tag, value string, data interface{}) {
 	values, found := i.fields[tag]
 
@@ -103,8 +134,10 @@ func (i *IntrospectorCompliant) SetField(tag, value string, data interface{}) {
 		return
 	}
 
-	if f.CanSet() {
-		f.Set(reflect.ValueOf(data))
+	// values of other types are ignored, like unknown fields
+	v := reflect.ValueOf(data)
+	if f.CanSet() && v.IsValid() && v.Type().AssignableTo(f.Type()) {
+		f.Set(v)
 	}
 }
 
@@ -124,6 +157,24 @@ func (i *IntrospectorCompliant) Field(tag, value string) interface{} {
 	return emptyInterface(f)
 }
 
+// FieldAddr returns the address of the field, even when it's a nil pointer,
+// so it can be allocated
+func (i *IntrospectorCompliant) FieldAddr(tag, value string) interface{} {
+	f, found := i.fields[tag][value]
+
+	if !found || !f.CanAddr() || !f.Addr().CanInterface() {
+		return nil
+	}
+
+	return f.Addr().Interface()
+}
+
+// FieldLayout returns the layout of a time.Time field, given by the layout
+// tag (eg: `query:"since" layout:"date"`)
+func (i *IntrospectorCompliant) FieldLayout(tag, value string) string {
+	return i.layouts[tag][value]
+}
+
 func (i *IntrospectorCompliant) KeysWithTag(tag string) []string {
 	keys := make([]string, 0, len(i.fields[tag]))
 
@@ -115,6 +115,12 @@ func TestIntrospectorBeforeUnknownField(t *testing.T) {
 		t.Errorf("Both objects are expected to be equal:\n%s", tests.Diff(copied, object))
 	}
 
+	object.SetField("field", "f", "wrong type")
+
+	if copied.F != object.F {
+		t.Errorf("Both objects are expected to be equal:\n%s", tests.Diff(copied, object))
+	}
+
 	f := object.Field("missing", "field")
 
 	if f != nil {
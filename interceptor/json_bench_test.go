@@ -125,6 +125,43 @@ func TestJSONAfter(t *testing.T) {
 	}
 }
 
+func TestJSONAfterWritten(t *testing.T) {
+	r, err := http.NewRequest("GET", "/", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	handler := &mockJSONHandler{
+		req:  r,
+		resp: handy.NewStatusResponseWriter(w),
+	}
+	handler.Response.Cinco = 5
+
+	i := NewIntrospector(handler)
+	i.Before()
+
+	// The handler answered by itself, so the codec must not write again
+	handler.resp.WriteHeader(http.StatusAccepted)
+	handler.resp.Write([]byte("custom"))
+
+	u := NewJSONCodec(handler)
+	status := u.After(http.StatusAccepted)
+
+	if status != http.StatusAccepted {
+		t.Errorf("Wrong status code. Expecting “202”; found “%d”", status)
+	}
+
+	if w.Body.String() != "custom" {
+		t.Errorf("Wrong response. Expecting “custom”; found “%s”", w.Body.String())
+	}
+
+	if sw := handler.resp.(*handy.StatusResponseWriter); sw.BytesWritten() != len("custom") {
+		t.Errorf("Wrong number of bytes written. Expecting %d; found %d", len("custom"), sw.BytesWritten())
+	}
+}
+
 var (
 	payload = `
 {
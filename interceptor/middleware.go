@@ -0,0 +1,51 @@
+package interceptor
+
+import (
+	"net/http"
+
+	"github.com/trajber/handy"
+)
+
+// Middleware adapts the standard func(http.Handler) http.Handler middlewares
+// (eg: compression, CORS) to interceptors. The middleware runs around the rest
+// of the interceptor chain and the handler, that see the ResponseWriter and
+// the request given by it
+type Middleware struct {
+	NopInterceptor
+
+	middleware func(http.Handler) http.Handler
+}
+
+func NewMiddleware(m func(http.Handler) http.Handler) *Middleware {
+	return &Middleware{middleware: m}
+}
+
+// MiddlewareFactory builds a Middleware for each request, so the middleware
+// can be registered with Handy.Use, groups or route options
+func MiddlewareFactory(m func(http.Handler) http.Handler) handy.InterceptorFactory {
+	return func(handy.Handler) handy.Interceptor {
+		return NewMiddleware(m)
+	}
+}
+
+func (m *Middleware) Wrap(w http.ResponseWriter, r *http.Request, next func(http.ResponseWriter, *http.Request) int) int {
+	called := false
+	status := 0
+	recorder := handy.NewStatusResponseWriter(w)
+
+	m.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		status = next(w, r)
+	})).ServeHTTP(recorder, r)
+
+	// The middleware answered the request by itself (eg: a CORS preflight)
+	if !called {
+		if !recorder.Written() {
+			return http.StatusOK
+		}
+
+		return recorder.Status()
+	}
+
+	return status
+}
@@ -0,0 +1,89 @@
+package interceptor
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/trajber/handy"
+)
+
+type upperWriter struct {
+	http.ResponseWriter
+}
+
+func (w upperWriter) Write(b []byte) (int, error) {
+	return w.ResponseWriter.Write(bytes.ToUpper(b))
+}
+
+type mockMiddlewareHandler struct {
+	handy.DefaultHandler
+}
+
+func (m *mockMiddlewareHandler) Get() int {
+	m.ResponseWriter().Write([]byte("hello " + m.Req().Header.Get("X-User")))
+	return http.StatusOK
+}
+
+func TestMiddleware(t *testing.T) {
+	var status int
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			r = r.WithContext(r.Context())
+			r.Header.Set("X-User", "john")
+			next.ServeHTTP(upperWriter{w}, r)
+		})
+	}
+
+	mux := handy.NewHandy()
+	mux.Use(func(handy.Handler) handy.Interceptor {
+		return AfterInterceptorFunc(func(s int) int {
+			status = s
+			return 0
+		})
+	})
+
+	mux.Handle("/hello", func() handy.Handler {
+		return new(mockMiddlewareHandler)
+	}, handy.WithInterceptors(MiddlewareFactory(auth)))
+
+	data := []struct {
+		authorization  string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{authorization: "secret", expectedStatus: http.StatusOK, expectedBody: "HELLO JOHN"},
+		{expectedStatus: http.StatusUnauthorized},
+	}
+
+	for i, item := range data {
+		status = 0
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", "/hello", nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.authorization != "" {
+			r.Header.Set("Authorization", item.authorization)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if status != item.expectedStatus || w.Code != item.expectedStatus {
+			t.Errorf("Item %d: Unexpected status. Expecting %d; found %d in the interceptor and %d in the response", i, item.expectedStatus, status, w.Code)
+		}
+
+		if w.Body.String() != item.expectedBody {
+			t.Errorf("Item %d: Unexpected body. Expecting “%s”; found “%s”", i, item.expectedBody, w.Body.String())
+		}
+	}
+}
@@ -23,14 +23,8 @@ func (q *QueryString) Before() int {
 		q.handler.Req().ParseMultipartForm(32 << 20) // 32 MB
 	}
 
-	for key, values := range q.handler.Req().Form {
-		if len(values) == 0 {
-			continue
-		}
-
-		if err := setValue(q.handler.Field("query", key), values[0]); err != nil {
-			return http.StatusBadRequest
-		}
+	if err := bindValues(q.handler, "query", q.handler.Req().Form); err != nil {
+		return http.StatusBadRequest
 	}
 
 	return 0
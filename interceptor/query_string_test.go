@@ -6,7 +6,9 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/trajber/handy"
 )
@@ -238,3 +240,217 @@ func TestQueryStringBefore(t *testing.T) {
 		}
 	}
 }
+
+type mockQueryStringCollectionsHandler struct {
+	handy.DefaultHandler
+	IntrospectorCompliant
+
+	request *http.Request
+
+	IDs    []int             `query:"id"`
+	Names  []string          `query:"name"`
+	Pair   [2]float64        `query:"pair"`
+	IPs    []net.IP          `query:"ip"`
+	Filter map[string]string `query:"filter"`
+	Ranges map[string][]int  `query:"range"`
+}
+
+func (m mockQueryStringCollectionsHandler) Req() *http.Request {
+	return m.request
+}
+
+func TestQueryStringBeforeCollections(t *testing.T) {
+	data := []struct {
+		description    string
+		queryString    string
+		expected       mockQueryStringCollectionsHandler
+		expectedStatus int
+	}{
+		{
+			description: "it should load repeated and comma-separated values",
+			queryString: "id=1&id=2,3&name=a&name=b&pair=1.5,2.5&ip=10.0.0.1,10.0.0.2",
+			expected: mockQueryStringCollectionsHandler{
+				IDs:   []int{1, 2, 3},
+				Names: []string{"a", "b"},
+				Pair:  [2]float64{1.5, 2.5},
+				IPs:   []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")},
+			},
+		},
+		{
+			description: "it should load prefixed keys into maps",
+			queryString: "filter[name]=x&filter[kind]=y&range[age]=18,30",
+			expected: mockQueryStringCollectionsHandler{
+				Filter: map[string]string{"name": "x", "kind": "y"},
+				Ranges: map[string][]int{"age": {18, 30}},
+			},
+		},
+		{
+			description:    "it should fail to load an invalid element",
+			queryString:    "id=1&id=x",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "it should fail to load too many values into an array",
+			queryString:    "pair=1,2,3",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i, item := range data {
+		request, err := http.NewRequest("GET", "http://um.com.br?"+item.queryString, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := &mockQueryStringCollectionsHandler{request: request}
+
+		introspector := NewIntrospector(handler)
+		introspector.Before()
+
+		queryString := NewQueryString(handler)
+		status := queryString.Before()
+
+		if status != item.expectedStatus {
+			t.Errorf("Item %d, “%s”: mismatch HTTP status. Expecting “%d”; found “%d”", i, item.description, item.expectedStatus, status)
+		}
+
+		if status != 0 {
+			continue
+		}
+
+		handler.DefaultHandler = handy.DefaultHandler{}
+		handler.IntrospectorCompliant = IntrospectorCompliant{}
+		handler.request = nil
+
+		if !reflect.DeepEqual(*handler, item.expected) {
+			t.Errorf("Item %d, “%s”: wrong values. Expecting “%+v”; found “%+v”", i, item.description, item.expected, *handler)
+		}
+	}
+}
+
+type level int
+
+func (l *level) UnmarshalText(data []byte) error {
+	switch string(data) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("Unknown level “%s”", data)
+	}
+
+	return nil
+}
+
+type mockQueryStringOptionalHandler struct {
+	handy.DefaultHandler
+	IntrospectorCompliant
+
+	request *http.Request
+
+	Limit   *int          `query:"limit"`
+	Offset  *int          `query:"offset"`
+	IDs     *[]int        `query:"id"`
+	Timeout time.Duration `query:"timeout"`
+	Since   time.Time     `query:"since" layout:"date"`
+	Before  time.Time     `query:"before" layout:"date"`
+	At      time.Time     `query:"at" layout:"2006-01-02 15:04"`
+	Until   time.Time     `query:"until"`
+	Level   level         `query:"level"`
+	Levels  []level       `query:"levels"`
+	Minimum *level        `query:"minimum"`
+}
+
+func (m mockQueryStringOptionalHandler) Req() *http.Request {
+	return m.request
+}
+
+func TestQueryStringBeforePointersAndTimes(t *testing.T) {
+	limit := 10
+	ids := []int{1, 2}
+	minimum := level(1)
+
+	data := []struct {
+		description    string
+		queryString    string
+		expected       mockQueryStringOptionalHandler
+		expectedStatus int
+	}{
+		{
+			description: "it should allocate only the parameters that are present",
+			queryString: "limit=10&id=1,2&minimum=low",
+			expected: mockQueryStringOptionalHandler{
+				Limit:   &limit,
+				IDs:     &ids,
+				Minimum: &minimum,
+			},
+		},
+		{
+			description: "it should parse durations, times and custom types",
+			queryString: "timeout=5s&since=2015-05-07&until=2015-05-08T10:00:00Z&level=high&levels=low,high",
+			expected: mockQueryStringOptionalHandler{
+				Timeout: 5 * time.Second,
+				Since:   time.Date(2015, 5, 7, 0, 0, 0, 0, time.UTC),
+				Until:   time.Date(2015, 5, 8, 10, 0, 0, 0, time.UTC),
+				Level:   2,
+				Levels:  []level{1, 2},
+			},
+		},
+		{
+			description: "it should use the layout of each field",
+			queryString: "since=2015-05-07&before=2015-05-09&at=2015-05-07+10:30",
+			expected: mockQueryStringOptionalHandler{
+				Since:  time.Date(2015, 5, 7, 0, 0, 0, 0, time.UTC),
+				Before: time.Date(2015, 5, 9, 0, 0, 0, 0, time.UTC),
+				At:     time.Date(2015, 5, 7, 10, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			description:    "it should fail to load an invalid duration",
+			queryString:    "timeout=5",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "it should fail to load a time in another layout",
+			queryString:    "since=2015-05-07T10:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "it should fail to load an invalid pointer value",
+			queryString:    "minimum=medium",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i, item := range data {
+		request, err := http.NewRequest("GET", "http://um.com.br?"+item.queryString, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := &mockQueryStringOptionalHandler{request: request}
+
+		introspector := NewIntrospector(handler)
+		introspector.Before()
+
+		queryString := NewQueryString(handler)
+		status := queryString.Before()
+
+		if status != item.expectedStatus {
+			t.Errorf("Item %d, “%s”: mismatch HTTP status. Expecting “%d”; found “%d”", i, item.description, item.expectedStatus, status)
+		}
+
+		if status != 0 {
+			continue
+		}
+
+		handler.DefaultHandler = handy.DefaultHandler{}
+		handler.IntrospectorCompliant = IntrospectorCompliant{}
+		handler.request = nil
+
+		if !reflect.DeepEqual(*handler, item.expected) {
+			t.Errorf("Item %d, “%s”: wrong values. Expecting “%+v”; found “%+v”", i, item.description, item.expected, *handler)
+		}
+	}
+}
@@ -6,13 +6,42 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
-func setValue(ptr interface{}, value string) error {
+// TimeLayouts are the named layouts that can be used in the layout tag of
+// time.Time fields (eg: `query:"since" layout:"date"`). Any other value is
+// used as the layout itself. Without the tag, times are parsed as RFC 3339
+var TimeLayouts = map[string]string{
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04:05",
+	"time":     "15:04:05",
+	"rfc1123":  time.RFC1123,
+	"rfc3339":  time.RFC3339,
+	"unixdate": time.UnixDate,
+}
+
+// setValue converts the value to the type of the field. The layout is used
+// by time.Time fields
+func setValue(ptr interface{}, value, layout string) error {
 	switch f := ptr.(type) {
 	case nil:
 		return nil
 
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		*f = d
+
+	case *time.Time:
+		return setValueTime(f, value, layout)
+
+	case encoding.TextUnmarshaler:
+		return f.UnmarshalText([]byte(value))
+
 	case *string:
 		*f = value
 
@@ -30,7 +59,173 @@ func setValue(ptr interface{}, value string) error {
 		return setValueFloat(ptr, value)
 
 	default:
-		return setValueUnmarshaler(ptr, value)
+		return setValuePointer(ptr, value, layout)
+	}
+
+	return nil
+}
+
+// setValues sets the field with all the values of a repeated parameter.
+// Slices receive every value, also splitting the comma-separated ones, and
+// arrays are filled in order; other fields receive the first value
+func setValues(ptr interface{}, values []string, layout string) error {
+	if ptr == nil || len(values) == 0 {
+		return nil
+	}
+
+	if _, ok := ptr.(encoding.TextUnmarshaler); ok {
+		return setValue(ptr, values[0], layout)
+	}
+
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr {
+		return setValue(ptr, values[0], layout)
+	}
+
+	switch field := v.Elem(); field.Kind() {
+	case reflect.Ptr:
+		// pointers to slices or arrays are allocated only when there are
+		// values for them
+		target := field
+		if target.IsNil() {
+			target = reflect.New(field.Type().Elem())
+		}
+
+		if err := setValues(target.Interface(), values, layout); err != nil {
+			return err
+		}
+
+		field.Set(target)
+
+	case reflect.Slice:
+		elements := splitValues(values)
+		slice := reflect.MakeSlice(field.Type(), len(elements), len(elements))
+		for i, element := range elements {
+			if err := setValue(slice.Index(i).Addr().Interface(), element, layout); err != nil {
+				return err
+			}
+		}
+
+		field.Set(slice)
+
+	case reflect.Array:
+		elements := splitValues(values)
+		if len(elements) > field.Len() {
+			return fmt.Errorf("Too many values for an array of %d elements", field.Len())
+		}
+
+		array := reflect.New(field.Type()).Elem()
+		for i, element := range elements {
+			if err := setValue(array.Index(i).Addr().Interface(), element, layout); err != nil {
+				return err
+			}
+		}
+
+		field.Set(array)
+
+	default:
+		return setValue(ptr, values[0], layout)
+	}
+
+	return nil
+}
+
+func splitValues(values []string) []string {
+	var elements []string
+	for _, value := range values {
+		elements = append(elements, strings.Split(value, ",")...)
+	}
+
+	return elements
+}
+
+// setMapValue sets the entry of a map field, from a prefixed parameter like
+// "filter[name]"
+func setMapValue(ptr interface{}, key string, values []string, layout string) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Map {
+		return fmt.Errorf("Unsuported value type: %#v", ptr)
+	}
+
+	field := v.Elem()
+	if field.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("Unsuported map key type: %s", field.Type().Key())
+	}
+
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+
+	value := reflect.New(field.Type().Elem())
+	if err := setValues(value.Interface(), values, layout); err != nil {
+		return err
+	}
+
+	field.SetMapIndex(reflect.ValueOf(key).Convert(field.Type().Key()), value.Elem())
+	return nil
+}
+
+// splitMapKey splits a parameter like "filter[name]" in the name of the map
+// and the key of the entry
+func splitMapKey(parameter string) (name, key string, ok bool) {
+	i := strings.Index(parameter, "[")
+	if i <= 0 || !strings.HasSuffix(parameter, "]") {
+		return "", "", false
+	}
+
+	return parameter[:i], parameter[i+1 : len(parameter)-1], true
+}
+
+type fielder interface {
+	Field(string, string) interface{}
+}
+
+// addrFielder is implemented by IntrospectorCompliant, that can give the
+// address of pointer fields, so they are allocated only when needed
+type addrFielder interface {
+	FieldAddr(string, string) interface{}
+}
+
+type layoutFielder interface {
+	FieldLayout(string, string) string
+}
+
+// bindingField returns the field to be set from a parameter and the layout
+// of its times
+func bindingField(h fielder, tag, name string) (interface{}, string) {
+	var layout string
+	if l, ok := h.(layoutFielder); ok {
+		layout = l.FieldLayout(tag, name)
+	}
+
+	if a, ok := h.(addrFielder); ok {
+		return a.FieldAddr(tag, name), layout
+	}
+
+	return h.Field(tag, name), layout
+}
+
+// bindValues sets the fields with the given tag from the parameters
+func bindValues(h fielder, tag string, parameters map[string][]string) error {
+	for parameter, values := range parameters {
+		if len(values) == 0 {
+			continue
+		}
+
+		if name, key, ok := splitMapKey(parameter); ok {
+			if field, layout := bindingField(h, tag, name); field != nil {
+				if err := setMapValue(field, key, values, layout); err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+
+		field, layout := bindingField(h, tag, parameter)
+		if err := setValues(field, values, layout); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -72,11 +267,43 @@ func setValueFloat(ptr interface{}, value string) error {
 	return nil
 }
 
-func setValueUnmarshaler(ptr interface{}, value string) error {
-	u, ok := ptr.(encoding.TextUnmarshaler)
-	if !ok {
+func setValueTime(t *time.Time, value, layout string) error {
+	if layout == "" {
+		return t.UnmarshalText([]byte(value))
+	}
+
+	if named, ok := TimeLayouts[layout]; ok {
+		layout = named
+	}
+
+	parsed, err := time.Parse(layout, value)
+	if err != nil {
+		return err
+	}
+
+	*t = parsed
+	return nil
+}
+
+// setValuePointer sets pointer fields, allocating them only when they are
+// nil. The value pointed to is set like any other field, including the
+// detection of encoding.TextUnmarshaler on its pointer receivers
+func setValuePointer(ptr interface{}, value, layout string) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Ptr {
 		return fmt.Errorf("Unsuported value type: %#v", ptr)
 	}
 
-	return u.UnmarshalText([]byte(value))
+	field := v.Elem()
+	if !field.IsNil() {
+		return setValue(field.Interface(), value, layout)
+	}
+
+	target := reflect.New(field.Type().Elem())
+	if err := setValue(target.Interface(), value, layout); err != nil {
+		return err
+	}
+
+	field.Set(target)
+	return nil
 }
@@ -23,13 +23,13 @@ func NewURIVars(h uriVarsHandler) *URIVars {
 
 func (u *URIVars) Before() int {
 	for k, value := range u.handler.URIVars() {
-		field := u.handler.Field("urivar", k)
+		field, layout := bindingField(u.handler, "urivar", k)
 
 		if field == nil {
 			continue
 		}
 
-		if err := setValue(field, value); err != nil {
+		if err := setValue(field, value, layout); err != nil {
 			return http.StatusBadRequest
 		}
 	}
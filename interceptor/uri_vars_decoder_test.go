@@ -4,6 +4,7 @@ import (
 	"net"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/trajber/handy"
 )
@@ -29,6 +30,10 @@ type mockURIVarsHandler struct {
 	F32 float32 `urivar:"f32"`
 	F64 float64 `urivar:"f64"`
 	IP  net.IP  `urivar:"ip"`
+
+	D      time.Duration `urivar:"d"`
+	P      *int          `urivar:"p"`
+	Absent *int          `urivar:"absent"`
 }
 
 func (m mockURIVarsHandler) URIVars() handy.URIVars {
@@ -52,6 +57,8 @@ func TestURIVarsBefore(t *testing.T) {
 		"f32":   "27.1",
 		"f64":   "27.2",
 		"ip":    "192.168.0.1",
+		"d":     "1m30s",
+		"p":     "28",
 		"extra": "Extra field",
 	}
 
@@ -125,6 +132,18 @@ func TestURIVarsBefore(t *testing.T) {
 		t.Errorf("Wrong value. Expecting “192.168.0.1”; found “%s”", handler.IP)
 	}
 
+	if handler.D != 90*time.Second {
+		t.Errorf("Wrong value. Expecting “1m30s”; found “%s”", handler.D)
+	}
+
+	if handler.P == nil || *handler.P != 28 {
+		t.Errorf("Wrong value. Expecting “28”; found “%v”", handler.P)
+	}
+
+	if handler.Absent != nil {
+		t.Errorf("Wrong value. Expecting nil; found “%v”", handler.Absent)
+	}
+
 	urivars["i"] = "dezessete"
 	handler = &mockURIVarsHandler{urivars: urivars}
 	i = NewIntrospector(handler)
@@ -0,0 +1,53 @@
+package interceptor
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+)
+
+// XMLCodec decodes the request field and encodes the response field of the
+// handler as XML
+type XMLCodec struct {
+	codecInterceptor
+}
+
+func NewXMLCodec(h codecHandler) *XMLCodec {
+	return &XMLCodec{codecInterceptor{
+		handler:      h,
+		codec:        XML,
+		decodeStatus: http.StatusBadRequest,
+	}}
+}
+
+// XML is the codec of the "application/xml" and "text/xml" media types
+var XML Codec = xmlCodec{}
+
+type xmlCodec struct{}
+
+func (xmlCodec) MediaTypes() []string {
+	return []string{"application/xml", "text/xml"}
+}
+
+func (xmlCodec) Decode(r io.Reader, v interface{}) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		if err := decoder.Decode(v); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+func (xmlCodec) Encode(w io.Writer, v interface{}) error {
+	buf, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
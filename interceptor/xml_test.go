@@ -0,0 +1,113 @@
+package interceptor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mockXMLHandler struct {
+	IntrospectorCompliant
+
+	req     *http.Request
+	resp    http.ResponseWriter
+	Request struct {
+		XMLName struct{} `xml:"user"`
+		ID      int      `xml:"id,attr"`
+		Name    string   `xml:"name"`
+	} `request:"put"`
+	Response struct {
+		XMLName struct{} `xml:"user"`
+		ID      int      `xml:"id,attr"`
+		Name    string   `xml:"name"`
+	} `response:"get"`
+	Header http.Header `response:"header"`
+}
+
+func (m mockXMLHandler) Req() *http.Request {
+	return m.req
+}
+
+func (m mockXMLHandler) ResponseWriter() http.ResponseWriter {
+	return m.resp
+}
+
+func TestXMLBefore(t *testing.T) {
+	req, err := http.NewRequest("PUT", "/", strings.NewReader(`<user id="7"><name>John</name></user>`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &mockXMLHandler{req: req}
+	i := NewIntrospector(handler)
+	i.Before()
+	x := NewXMLCodec(handler)
+	status := x.Before()
+
+	if status != 0 {
+		t.Errorf("Wrong status code. Expecting “0”; found “%d”", status)
+	}
+
+	if handler.Request.ID != 7 || handler.Request.Name != "John" {
+		t.Errorf("Wrong request. Found “%+v”", handler.Request)
+	}
+}
+
+func TestXMLBeforeMalformed(t *testing.T) {
+	req, err := http.NewRequest("PUT", "/", strings.NewReader(`<user id="7"><name>John</user>`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &mockXMLHandler{req: req}
+	i := NewIntrospector(handler)
+	i.Before()
+	x := NewXMLCodec(handler)
+
+	if status := x.Before(); status != http.StatusBadRequest {
+		t.Errorf("Wrong status code. Expecting “%d”; found “%d”", http.StatusBadRequest, status)
+	}
+}
+
+func TestXMLAfter(t *testing.T) {
+	r, err := http.NewRequest("GET", "/", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	handler := &mockXMLHandler{req: r, resp: w}
+	handler.Response.ID = 7
+	handler.Response.Name = "John"
+	handler.Header = http.Header{"X-Custom": {"value"}}
+
+	i := NewIntrospector(handler)
+	i.Before()
+	x := NewXMLCodec(handler)
+	status := x.After(http.StatusOK)
+
+	if status != http.StatusOK {
+		t.Errorf("Wrong status code. Expecting “200”; found “%d”", status)
+	}
+
+	expected := `<user id="7"><name>John</name></user>`
+
+	if w.Body.String() != expected {
+		t.Errorf("Wrong response. Expecting “%s”; found “%s”", expected, w.Body.String())
+	}
+
+	expectedHeader := http.Header{
+		"Content-Type":   {"application/xml"},
+		"Content-Length": {"37"},
+		"X-Custom":       {"value"},
+	}
+
+	if !reflect.DeepEqual(w.Header(), expectedHeader) {
+		t.Errorf("Wrong header. Expecting “%v”; found “%v”", expectedHeader, w.Header())
+	}
+}
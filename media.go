@@ -0,0 +1,94 @@
+package handy
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is one of the media ranges of an Accept header, like
+// "text/*;q=0.5"
+type MediaRange struct {
+	MediaType string
+	Quality   float64
+}
+
+// Matches checks if the media type is in the range, that can have wildcards
+// like "*/*" or "application/*"
+func (m MediaRange) Matches(mediaType string) bool {
+	if m.MediaType == "*/*" || m.MediaType == mediaType {
+		return true
+	}
+
+	if strings.HasSuffix(m.MediaType, "/*") {
+		return strings.HasPrefix(mediaType, m.MediaType[:len(m.MediaType)-1])
+	}
+
+	return false
+}
+
+// Specificity ranks the media ranges: "*/*" is 0, ranges like "text/*" are 1
+// and a single media type is 2
+func (m MediaRange) Specificity() int {
+	switch {
+	case m.MediaType == "*/*":
+		return 0
+	case strings.HasSuffix(m.MediaType, "/*"):
+		return 1
+	}
+
+	return 2
+}
+
+// AcceptHeader holds the media ranges of an Accept header, including the ones
+// refused with a zero quality (eg: "text/html;q=0")
+type AcceptHeader []MediaRange
+
+// ParseAccept reads the media ranges of the Accept header, in the order they
+// appear. Invalid ranges are left out
+func ParseAccept(header string) AcceptHeader {
+	var ranges AcceptHeader
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, MediaRange{MediaType: mediaType, Quality: quality})
+	}
+
+	return ranges
+}
+
+// Match returns the most specific range that matches the media type, that
+// defines how much the media type is accepted. A zero quality means the media
+// type was refused, even if a wildcard range also matches it
+func (a AcceptHeader) Match(mediaType string) (MediaRange, bool) {
+	var best MediaRange
+	found := false
+	for _, m := range a {
+		if m.Matches(mediaType) && (!found || m.Specificity() > best.Specificity()) {
+			best, found = m, true
+		}
+	}
+
+	return best, found
+}
+
+// Quality tells how much the media type is accepted, from 0 (not acceptable)
+// to 1
+func (a AcceptHeader) Quality(mediaType string) float64 {
+	m, ok := a.Match(mediaType)
+	if !ok || m.Quality < 0 {
+		return 0
+	}
+
+	return m.Quality
+}
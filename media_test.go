@@ -0,0 +1,27 @@
+package handy
+
+import (
+	"testing"
+)
+
+func TestAcceptHeaderQuality(t *testing.T) {
+	data := []struct {
+		header    string
+		mediaType string
+		expected  float64
+	}{
+		{header: "text/html", mediaType: "text/html", expected: 1},
+		{header: "text/html", mediaType: "text/plain", expected: 0},
+		{header: "text/*;q=0.5, */*;q=0.1", mediaType: "text/plain", expected: 0.5},
+		{header: "text/*;q=0.5, */*;q=0.1", mediaType: "image/png", expected: 0.1},
+		{header: "*/*, text/html;q=0", mediaType: "text/html", expected: 0},
+		{header: "text/html;q=0.2, text/*", mediaType: "text/html", expected: 0.2},
+		{header: "text/html;q=x, */*;q=0.3", mediaType: "text/html", expected: 0.3},
+	}
+
+	for i, item := range data {
+		if q := ParseAccept(item.header).Quality(item.mediaType); q != item.expected {
+			t.Errorf("Item %d: wrong quality. Expecting “%v”; found “%v”", i, item.expected, q)
+		}
+	}
+}
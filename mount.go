@@ -0,0 +1,101 @@
+package handy
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// httpHandler adapts a http.Handler to Handler, serving all the HTTP methods
+// with it. As http.Handlers don't report the status of the response, it's
+// recorded for the interceptors
+type httpHandler struct {
+	DefaultHandler
+
+	handler http.Handler
+	strip   int
+}
+
+// HTTPHandler adapts a http.Handler to be registered with Handle, so it can be
+// wrapped by interceptors like any other handler
+func HTTPHandler(h http.Handler) Constructor {
+	return func() Handler {
+		return &httpHandler{handler: h}
+	}
+}
+
+func (h *httpHandler) Get() int     { return h.serve() }
+func (h *httpHandler) Post() int    { return h.serve() }
+func (h *httpHandler) Put() int     { return h.serve() }
+func (h *httpHandler) Delete() int  { return h.serve() }
+func (h *httpHandler) Patch() int   { return h.serve() }
+func (h *httpHandler) Head() int    { return h.serve() }
+func (h *httpHandler) Options() int { return h.serve() }
+
+// ExtraMethods serves any other HTTP method of the request with the
+// http.Handler too
+func (h *httpHandler) ExtraMethods() map[string]func() int {
+	if h.request == nil {
+		return nil
+	}
+
+	return map[string]func() int{h.request.Method: h.serve}
+}
+
+func (h *httpHandler) serve() int {
+	r := h.request
+	if h.strip > 0 {
+		stripped := new(http.Request)
+		*stripped = *r
+		stripped.URL = new(url.URL)
+		*stripped.URL = *r.URL
+		stripped.URL.Path = stripSegments(r.URL.Path, h.strip)
+		if r.URL.RawPath != "" {
+			stripped.URL.RawPath = stripSegments(r.URL.RawPath, h.strip)
+		}
+
+		r = stripped
+	}
+
+	w := NewStatusResponseWriter(h.response)
+	h.handler.ServeHTTP(w, r)
+
+	if !w.Written() {
+		return http.StatusOK
+	}
+
+	return w.Status()
+}
+
+// stripSegments removes the first n segments of the path
+func stripSegments(p string, n int) string {
+	for ; n > 0; n-- {
+		p = strings.TrimLeft(p, "/")
+		i := strings.Index(p, "/")
+		if i < 0 {
+			return "/"
+		}
+
+		p = p[i:]
+	}
+
+	return p
+}
+
+// Mount serves all the requests under the prefix with the http.Handler (eg:
+// http.FileServer), removing the prefix from the path of the request. The
+// rest of the path is also available in the URI variable "path"
+func (handy *Handy) Mount(prefix string, h http.Handler, options ...RouteOption) {
+	handy.Handle(mountPattern(prefix), mountConstructor(prefix, h), options...)
+}
+
+func mountPattern(prefix string) string {
+	return strings.TrimRight(strings.TrimSpace(prefix), "/") + "/{path*}"
+}
+
+func mountConstructor(prefix string, h http.Handler) Constructor {
+	strip := len(splitPath(prefix))
+	return func() Handler {
+		return &httpHandler{handler: h, strip: strip}
+	}
+}
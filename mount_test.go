@@ -0,0 +1,86 @@
+package handy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type statusInterceptor struct {
+	status *int
+}
+
+func (i *statusInterceptor) Before() int {
+	return 0
+}
+
+func (i *statusInterceptor) After(status int) int {
+	*i.status = status
+	return status
+}
+
+func TestMount(t *testing.T) {
+	var status int
+	var path string
+	var uriVars URIVars
+	mounted := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte(r.Method))
+	})
+
+	mux := NewHandy()
+	mux.Mount("/static/", mounted, WithInterceptors(func(h Handler) Interceptor {
+		uriVars = h.(*httpHandler).uriVars
+		return &statusInterceptor{status: &status}
+	}))
+	mux.Group("/api").Mount("/debug", mounted)
+
+	data := []struct {
+		method         string
+		uri            string
+		expectedPath   string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{method: "GET", uri: "/static/css/site.css", expectedPath: "/css/site.css", expectedStatus: http.StatusOK, expectedBody: "GET"},
+		{method: "GET", uri: "/static/", expectedPath: "/", expectedStatus: http.StatusOK, expectedBody: "GET"},
+		{method: "GET", uri: "/static/missing", expectedPath: "/missing", expectedStatus: http.StatusNotFound},
+		{method: "PROPFIND", uri: "/static/dir/", expectedPath: "/dir/", expectedStatus: http.StatusOK, expectedBody: "PROPFIND"},
+		{method: "POST", uri: "/api/debug/pprof", expectedPath: "/pprof", expectedBody: "POST"},
+	}
+
+	for i, item := range data {
+		status, path = 0, ""
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(item.method, item.uri, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if path != item.expectedPath {
+			t.Errorf("Item %d: Unexpected path. Expecting “%s”; found “%s”", i, item.expectedPath, path)
+		}
+
+		if status != item.expectedStatus {
+			t.Errorf("Item %d: Unexpected status in the interceptor. Expecting %d; found %d", i, item.expectedStatus, status)
+		}
+
+		if item.expectedBody != "" && w.Body.String() != item.expectedBody {
+			t.Errorf("Item %d: Unexpected body. Expecting “%s”; found “%s”", i, item.expectedBody, w.Body.String())
+		}
+	}
+
+	// the last request to "/static/" was for "/static/dir/"
+	if uriVars["path"] != "dir" {
+		t.Errorf("Unexpected path variable. Expecting “dir”; found “%s”", uriVars["path"])
+	}
+}
@@ -3,7 +3,9 @@ package handy
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,11 +19,17 @@ var (
 )
 
 type Handy struct {
-	mu             sync.RWMutex
-	router         *Router
-	currentClients int32
-	CountClients   bool
-	Recover        func(interface{})
+	mu              sync.Mutex
+	router          atomic.Value
+	draft           *Router
+	dirty           int32
+	interceptors    atomic.Value
+	currentClients  int32
+	CountClients    bool
+	PathPolicy      PathPolicy
+	UseRawPath      bool
+	CaseInsensitive bool
+	Recover         func(interface{})
 }
 
 type Constructor func() Handler
@@ -32,40 +40,319 @@ func SetHandlerInfo(h Handler, w http.ResponseWriter, r *http.Request, u URIVars
 
 func NewHandy() *Handy {
 	handy := new(Handy)
-	handy.router = NewRouter()
+	handy.router.Store(NewRouter())
+	handy.interceptors.Store([]InterceptorFactory(nil))
 	return handy
 }
 
-func (handy *Handy) Handle(pattern string, h Constructor) {
+// Router returns a copy of the current route table, that can be changed
+// and then put in use with SetRouter
+func (handy *Handy) Router() *Router {
+	return handy.loadRouter().Clone()
+}
+
+// SetRouter replaces the route table atomically. Requests being served keep
+// using the old one until they finish
+func (handy *Handy) SetRouter(r *Router) {
 	handy.mu.Lock()
 	defer handy.mu.Unlock()
 
-	if err := handy.router.AppendRoute(pattern, h); err != nil {
+	handy.draft = nil
+	atomic.StoreInt32(&handy.dirty, 0)
+	handy.router.Store(r)
+}
+
+// loadRouter returns the route table in use, putting in use the changes made
+// since the last call
+func (handy *Handy) loadRouter() *Router {
+	if atomic.LoadInt32(&handy.dirty) != 0 {
+		handy.publish()
+	}
+
+	return handy.router.Load().(*Router)
+}
+
+func (handy *Handy) publish() {
+	handy.mu.Lock()
+	defer handy.mu.Unlock()
+
+	if handy.draft != nil {
+		handy.router.Store(handy.draft)
+		handy.draft = nil
+	}
+
+	atomic.StoreInt32(&handy.dirty, 0)
+}
+
+// update changes a private copy of the route table, that is only put in use
+// by the next request, so registering many routes copies the table once.
+// Requests never see a table that is being changed
+func (handy *Handy) update(f func(*Router) error) error {
+	handy.mu.Lock()
+	defer handy.mu.Unlock()
+
+	if handy.draft == nil {
+		handy.draft = handy.router.Load().(*Router).Clone()
+	}
+
+	if err := f(handy.draft); err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&handy.dirty, 1)
+	return nil
+}
+
+func (handy *Handy) Handle(pattern string, h Constructor, options ...RouteOption) {
+	err := handy.update(func(r *Router) error {
+		return r.AppendRoute(pattern, h, options...)
+	})
+
+	if err != nil {
+		panic("Cannot append route;" + err.Error())
+	}
+}
+
+func (handy *Handy) HandleFunc(method, pattern string, f HandlerFunc, options ...RouteOption) {
+	err := handy.update(func(r *Router) error {
+		return r.AppendRouteFunc(method, pattern, f, options...)
+	})
+
+	if err != nil {
 		panic("Cannot append route;" + err.Error())
 	}
 }
 
+// HandleHost registers a route that only matches requests to the host. The
+// wildcards of the host are added to the URI variables
+func (handy *Handy) HandleHost(host, pattern string, h Constructor, options ...RouteOption) {
+	handy.Handle(pattern, h, append(options, Host(host))...)
+}
+
+// Remove unregisters the handlers of the route whose host and conditions
+// are exactly the ones of the options. The routes of the same path with
+// other conditions are kept; without options, only the route without
+// conditions is removed
+func (handy *Handy) Remove(pattern string, options ...RouteOption) error {
+	return handy.update(func(r *Router) error {
+		return r.RemoveRoute(pattern, options...)
+	})
+}
+
+// Replace swaps the handlers of the route whose host and conditions are
+// exactly the ones of the options for the given one, with no moment where
+// the route is missing. The interceptors and the name of the route are kept,
+// unless new ones are given
+func (handy *Handy) Replace(pattern string, h Constructor, options ...RouteOption) error {
+	return handy.update(func(r *Router) error {
+		return r.ReplaceRoute(pattern, h, options...)
+	})
+}
+
+// Route describes a route registered in Handy
+type Route struct {
+	Pattern string
+	Methods []string
+}
+
+// Routes lists the registered routes, in the order they are tried
+func (handy *Handy) Routes() []Route {
+	var routes []Route
+	handy.loadRouter().Walk(func(pattern string, c Constructor, methods []string) error {
+		routes = append(routes, Route{Pattern: pattern, Methods: methods})
+		return nil
+	})
+
+	return routes
+}
+
+// URL builds the path of the route registered with the given name
+func (handy *Handy) URL(name string, vars URIVars) (string, error) {
+	return handy.loadRouter().URL(name, vars)
+}
+
+// Use registers interceptors that will wrap the chain of every request,
+// including the ones that don't match any route
+func (handy *Handy) Use(interceptors ...InterceptorFactory) {
+	handy.mu.Lock()
+	defer handy.mu.Unlock()
+
+	current := handy.loadInterceptors()
+	all := make([]InterceptorFactory, 0, len(current)+len(interceptors))
+	all = append(all, current...)
+	handy.interceptors.Store(append(all, interceptors...))
+}
+
+func (handy *Handy) loadInterceptors() []InterceptorFactory {
+	return handy.interceptors.Load().([]InterceptorFactory)
+}
+
 func (handy *Handy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if handy.CountClients {
 		atomic.AddInt32(&handy.currentClients, 1)
 		defer atomic.AddInt32(&handy.currentClients, -1)
 	}
 
-	handy.mu.RLock()
-	defer handy.mu.RUnlock()
-
+	// The writer is wrapped below, and a wrapper like the one of HEAD
+	// requests may never send what is written to it
+	rw := w
 	defer func() {
 		if r := recover(); r != nil {
 			if handy.Recover != nil {
 				handy.Recover(r)
 			}
-			w.WriteHeader(http.StatusInternalServerError)
+			rw.WriteHeader(http.StatusInternalServerError)
 		}
 	}()
 
-	route, err := handy.router.Match(r.URL.Path)
+	router := handy.loadRouter()
+	scheme, host := requestHost(r)
+	p := r.URL.Path
+	if handy.UseRawPath {
+		p = r.URL.EscapedPath()
+	}
+
+	if handy.PathPolicy != PathLenient {
+		if clean := handy.canonicalPath(router, r, scheme, host, p); clean != p {
+			segments, err := handy.segments(clean)
+			if err == nil {
+				_, err = router.match(r, scheme, host, segments, handy.CaseInsensitive)
+			}
+
+			if err == nil && handy.PathPolicy != PathStrict {
+				u := *r.URL
+				u.Path, u.RawPath = clean, ""
+				if handy.UseRawPath {
+					u.Path, _ = url.PathUnescape(clean)
+					u.RawPath = clean
+				}
+
+				http.Redirect(w, r, u.RequestURI(), handy.PathPolicy.redirectStatus())
+			} else {
+				handy.noMatch(w, r, ErrRouteNotFound)
+			}
+			return
+		}
+	}
+
+	segments, err := handy.segments(p)
+	if err != nil {
+		handy.noMatch(w, r, ErrRouteNotFound)
+		return
+	}
+
+	route, err := router.match(r, scheme, host, segments, handy.CaseInsensitive)
 
 	if err != nil {
+		handy.noMatch(w, r, err)
+		return
+	}
+
+	var head *headResponseWriter
+	e := route.endpoint
+	if r.Method == "HEAD" && e.headFromGet {
+		// The handler will run as in a GET request, but without sending
+		// the body
+		head = &headResponseWriter{ResponseWriter: w}
+		w = head
+
+		get := *r
+		get.Method = "GET"
+		r = &get
+	}
+
+	// Interceptors and handlers share the writer, that tells if the response
+	// was already sent
+	w = NewStatusResponseWriter(w)
+
+	var h Handler
+	var factories []InterceptorFactory
+	f, isFunc := e.funcs[r.Method]
+	if isFunc || e.handler == nil {
+		// HandlerFuncs still need a state for the interceptors
+		h = new(DefaultHandler)
+		factories = e.funcInterceptors[r.Method]
+	} else {
+		h = e.handler()
+		factories = e.interceptors
+	}
+
+	SetHandlerInfo(h, w, r, route.URIVars)
+	h.setAllowedMethods(e.methods)
+
+	handy.intercept(h, factories, w, r, route.URIVars, func(w http.ResponseWriter, r *http.Request) int {
+		if isFunc {
+			return f(w, r, route.URIVars)
+		}
+
+		return dispatch(h, w, r, e.methods)
+	})
+
+	if head != nil {
+		head.flush()
+	}
+}
+
+// canonicalPath returns the canonical form of the path, that is escaped with
+// UseRawPath, so escaped slashes are not taken as separators. The trailing
+// slash is only kept for catch-all routes, as the handlers mounted on them,
+// like http.FileServer, redirect to it
+func (handy *Handy) canonicalPath(router *Router, r *http.Request, scheme, host, p string) string {
+	clean := cleanPath(p)
+	if !strings.HasSuffix(clean, "/") || clean == "/" {
+		return clean
+	}
+
+	segments, err := handy.segments(clean)
+	if err != nil {
+		return strings.TrimSuffix(clean, "/")
+	}
+
+	route, err := router.match(r, scheme, host, segments, handy.CaseInsensitive)
+	if err == nil && route.catchAll {
+		return clean
+	}
+
+	return strings.TrimSuffix(clean, "/")
+}
+
+// segments splits the path of the request. With UseRawPath the path is the
+// escaped one, and each segment is unescaped after splitting it, so URI
+// variables can contain slashes
+func (handy *Handy) segments(p string) ([]string, error) {
+	if !handy.UseRawPath {
+		return splitPath(p), nil
+	}
+
+	segments := splitPath(p)
+	for i, segment := range segments {
+		var err error
+		if segments[i], err = url.PathUnescape(segment); err != nil {
+			return nil, err
+		}
+	}
+
+	return segments, nil
+}
+
+// noMatch answers the requests without a route. When the path matches but
+// the request doesn't satisfy the conditions of its routes, the error tells
+// what was wrong with it
+func (handy *Handy) noMatch(w http.ResponseWriter, r *http.Request, err error) {
+	w = NewStatusResponseWriter(w)
+	h := new(DefaultHandler)
+	u := make(URIVars)
+	SetHandlerInfo(h, w, r, u)
+	handy.intercept(h, nil, w, r, u, func(w http.ResponseWriter, r *http.Request) int {
+		switch err {
+		case ErrNotAcceptable:
+			w.WriteHeader(http.StatusNotAcceptable)
+			return http.StatusNotAcceptable
+		case ErrUnsupportedMediaType:
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return http.StatusUnsupportedMediaType
+		}
+
 		if NoMatchFunc != nil {
 			NoMatchFunc(w, r)
 		} else {
@@ -75,12 +362,70 @@ func (handy *Handy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			// permanent.
 			w.WriteHeader(http.StatusNotFound)
 		}
-		return
+		return http.StatusNotFound
+	})
+}
+
+// dispatch calls the handler method that serves the HTTP method of the request
+func dispatch(h Handler, w http.ResponseWriter, r *http.Request, methods []string) int {
+	switch r.Method {
+	case "GET":
+		return h.Get()
+	case "POST":
+		return h.Post()
+	case "PUT":
+		return h.Put()
+	case "DELETE":
+		return h.Delete()
+	case "PATCH":
+		return h.Patch()
+	case "HEAD":
+		return h.Head()
+	case "OPTIONS":
+		return h.Options()
 	}
 
-	h := route.Handler()
-	SetHandlerInfo(h, w, r, route.URIVars)
+	if eh, ok := h.(ExtraMethodsHandler); ok {
+		if f := eh.ExtraMethods()[r.Method]; f != nil {
+			return f()
+		}
+	}
+
+	return methodNotAllowed(w, methods)
+}
+
+// intercept runs the method between the Before and After calls of the global
+// interceptors, the given ones and the handler's own chain, in this order
+func (handy *Handy) intercept(h Handler, factories []InterceptorFactory, w http.ResponseWriter, r *http.Request, u URIVars, method func(http.ResponseWriter, *http.Request) int) {
+	global := handy.loadInterceptors()
 	interceptors := h.Interceptors()
+	if n := len(global) + len(factories); n > 0 {
+		chain := make(InterceptorChain, 0, n+len(interceptors))
+		for _, factory := range global {
+			chain = append(chain, factory(h))
+		}
+
+		for _, factory := range factories {
+			chain = append(chain, factory(h))
+		}
+
+		interceptors = append(chain, interceptors...)
+	}
+
+	c := &chainRun{handler: h, uriVars: u, method: method}
+	c.run(interceptors, w, r)
+}
+
+// chainRun executes an interceptor chain. A WrapperInterceptor runs the rest
+// of the chain inside its Wrap call, with the ResponseWriter and the request
+// that it chose; the handler sees them until Wrap returns
+type chainRun struct {
+	handler Handler
+	uriVars URIVars
+	method  func(http.ResponseWriter, *http.Request) int
+}
+
+func (c *chainRun) run(interceptors InterceptorChain, w http.ResponseWriter, r *http.Request) int {
 	var status int
 
 	var timeBefore time.Time
@@ -98,8 +443,18 @@ func (handy *Handy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		// If the interceptor reported some status, interrupt the chain
 		if status != 0 {
-			interceptors = interceptors[:k+1]
-			goto write
+			return c.after(interceptors[:k+1], status)
+		}
+
+		if wrapper, ok := interceptor.(WrapperInterceptor); ok {
+			rest := interceptors[k+1:]
+			status = wrapper.Wrap(w, r, func(wrappedW http.ResponseWriter, wrappedR *http.Request) int {
+				c.setRequestInfo(wrappedW, wrappedR)
+				defer c.setRequestInfo(w, r)
+				return c.run(rest, wrappedW, wrappedR)
+			})
+
+			return c.after(interceptors[:k+1], status)
 		}
 	}
 
@@ -107,22 +462,7 @@ func (handy *Handy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		timeBefore = time.Now()
 	}
 
-	switch r.Method {
-	case "GET":
-		status = h.Get()
-	case "POST":
-		status = h.Post()
-	case "PUT":
-		status = h.Put()
-	case "DELETE":
-		status = h.Delete()
-	case "PATCH":
-		status = h.Patch()
-	case "HEAD":
-		status = h.Head()
-	default:
-		status = http.StatusMethodNotAllowed
-	}
+	status = c.method(w, r)
 
 	if ProfilingEnabled {
 		elapsed = time.Since(timeBefore).Seconds()
@@ -130,8 +470,13 @@ func (handy *Handy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ProfileFunc(msg)
 	}
 
-write:
-	// executing all After interceptors in reverse order
+	return c.after(interceptors, status)
+}
+
+// after executes all After interceptors in reverse order
+func (c *chainRun) after(interceptors InterceptorChain, status int) int {
+	var timeBefore time.Time
+	var elapsed float64
 	for k := len(interceptors) - 1; k >= 0; k-- {
 		if ProfilingEnabled {
 			timeBefore = time.Now()
@@ -148,4 +493,10 @@ write:
 			status = s
 		}
 	}
+
+	return status
+}
+
+func (c *chainRun) setRequestInfo(w http.ResponseWriter, r *http.Request) {
+	SetHandlerInfo(c.handler, w, r, c.uriVars)
 }
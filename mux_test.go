@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 )
 
@@ -183,3 +184,539 @@ func (m *mockHandler) Head() int {
 func (m *mockHandler) Interceptors() InterceptorChain {
 	return m.interceptors
 }
+
+func TestHandleFunc(t *testing.T) {
+	mux := NewHandy()
+	mux.Handle("/uri/{x}", func() Handler {
+		return new(mockGetHandler)
+	})
+
+	mux.HandleFunc("PATCH", "/uri/{x}", func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(u["x"]))
+		return http.StatusAccepted
+	})
+
+	mux.HandleFunc("GET", "/func", func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+		w.WriteHeader(http.StatusOK)
+		return http.StatusOK
+	})
+
+	data := []struct {
+		method         string
+		uri            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{method: "GET", uri: "/uri/foo", expectedStatus: http.StatusNoContent},
+		{method: "PATCH", uri: "/uri/foo", expectedStatus: http.StatusAccepted, expectedBody: "foo"},
+		{method: "GET", uri: "/func", expectedStatus: http.StatusOK},
+		{method: "POST", uri: "/func", expectedStatus: http.StatusMethodNotAllowed},
+	}
+
+	for i, item := range data {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(item.method, item.uri, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != item.expectedStatus {
+			t.Errorf("Item %d: wrong status. Expecting “%d”; found “%d”", i, item.expectedStatus, w.Code)
+		}
+
+		if w.Body.String() != item.expectedBody {
+			t.Errorf("Item %d: wrong body. Expecting “%s”; found “%s”", i, item.expectedBody, w.Body.String())
+		}
+	}
+}
+
+type mockGetHandler struct {
+	DefaultHandler
+}
+
+func (m *mockGetHandler) Get() int {
+	m.ResponseWriter().WriteHeader(http.StatusNoContent)
+	return http.StatusNoContent
+}
+
+func TestExtraMethods(t *testing.T) {
+	mux := NewHandy()
+	mux.Handle("/dav/{x}", func() Handler {
+		return new(mockDAVHandler)
+	})
+
+	mux.HandleFunc("REPORT", "/dav/{x}", func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+		w.WriteHeader(http.StatusOK)
+		return http.StatusOK
+	})
+
+	data := []struct {
+		method         string
+		expectedStatus int
+		expectedAllow  string
+	}{
+		{method: "PROPFIND", expectedStatus: http.StatusMultiStatus},
+		{method: "REPORT", expectedStatus: http.StatusOK},
+		{method: "OPTIONS", expectedStatus: http.StatusOK, expectedAllow: "GET, HEAD, PROPFIND, REPORT, OPTIONS"},
+	}
+
+	for i, item := range data {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(item.method, "/dav/foo", nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != item.expectedStatus {
+			t.Errorf("Item %d: wrong status. Expecting “%d”; found “%d”", i, item.expectedStatus, w.Code)
+		}
+
+		if allow := w.Header().Get("Allow"); allow != item.expectedAllow {
+			t.Errorf("Item %d: wrong Allow header. Expecting “%s”; found “%s”", i, item.expectedAllow, allow)
+		}
+	}
+}
+
+type mockDAVHandler struct {
+	mockGetHandler
+}
+
+func (m *mockDAVHandler) ExtraMethods() map[string]func() int {
+	return map[string]func() int{
+		"PROPFIND": m.Propfind,
+	}
+}
+
+func (m *mockDAVHandler) Propfind() int {
+	m.ResponseWriter().WriteHeader(http.StatusMultiStatus)
+	return http.StatusMultiStatus
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	mux := NewHandy()
+	mux.Handle("/uri", func() Handler {
+		return new(mockGetHandler)
+	})
+
+	mux.HandleFunc("DELETE", "/uri", func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+		return http.StatusNoContent
+	})
+
+	for _, method := range []string{"POST", "PUT", "PATCH", "TRACE"} {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(method, "/uri", nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: wrong status. Expecting “%d”; found “%d”", method, http.StatusMethodNotAllowed, w.Code)
+		}
+
+		expected := "GET, HEAD, DELETE, OPTIONS"
+		if allow := w.Header().Get("Allow"); allow != expected {
+			t.Errorf("%s: wrong Allow header. Expecting “%s”; found “%s”", method, expected, allow)
+		}
+	}
+}
+
+func TestHeadFromGet(t *testing.T) {
+	mux := NewHandy()
+	mux.Handle("/get", func() Handler {
+		return new(mockBodyHandler)
+	})
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("HEAD", "/get", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Wrong status. Expecting “%d”; found “%d”", http.StatusOK, w.Code)
+	}
+
+	if w.Body.Len() != 0 {
+		t.Errorf("Body sent in a HEAD request: “%s”", w.Body.String())
+	}
+
+	if length := w.Header().Get("Content-Length"); length != "11" {
+		t.Errorf("Wrong Content-Length. Expecting “11”; found “%s”", length)
+	}
+
+	// Handlers that implement Head should not fall back to Get
+
+	w = httptest.NewRecorder()
+	r, err = http.NewRequest("HEAD", "/head", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var handler *mockHandler
+	mux = NewHandy()
+	mux.Handle("/head", func() Handler {
+		handler = &mockHandler{
+			handleFunc: func() int {
+				return http.StatusOK
+			},
+		}
+		return handler
+	})
+
+	mux.ServeHTTP(w, r)
+
+	if handler.methodCalled != "HEAD" {
+		t.Errorf("Wrong method called. Expecting “HEAD”; found “%s”", handler.methodCalled)
+	}
+
+	// A Get that panics must not answer the HEAD request with success
+
+	w = httptest.NewRecorder()
+	r, err = http.NewRequest("HEAD", "/panic", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux = NewHandy()
+	mux.Handle("/panic", func() Handler {
+		return new(mockPanicHandler)
+	})
+
+	mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Wrong status. Expecting “%d”; found “%d”", http.StatusInternalServerError, w.Code)
+	}
+}
+
+type mockPanicHandler struct {
+	DefaultHandler
+}
+
+func (m *mockPanicHandler) Get() int {
+	panic("get failed")
+}
+
+type mockBodyHandler struct {
+	DefaultHandler
+}
+
+func (m *mockBodyHandler) Get() int {
+	m.ResponseWriter().Write([]byte("Hello World"))
+	return http.StatusOK
+}
+
+func TestStatusResponseWriter(t *testing.T) {
+	var written bool
+	var status, length int
+
+	mux := NewHandy()
+	mux.Handle("/get", func() Handler {
+		return new(mockBodyHandler)
+	}, WithInterceptors(func(h Handler) Interceptor {
+		return &statusAfterInterceptor{func() {
+			w := h.(*mockBodyHandler).ResponseWriter().(*StatusResponseWriter)
+			written, status, length = w.Written(), w.Status(), w.BytesWritten()
+		}}
+	}))
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/get", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux.ServeHTTP(w, r)
+
+	if !written || status != http.StatusOK || length != len("Hello World") {
+		t.Errorf("Unexpected state of the writer. Found written %t, status %d and %d bytes", written, status, length)
+	}
+}
+
+type statusAfterInterceptor struct {
+	after func()
+}
+
+func (i *statusAfterInterceptor) Before() int {
+	return 0
+}
+
+func (i *statusAfterInterceptor) After(status int) int {
+	i.after()
+	return status
+}
+
+func TestGlobalInterceptors(t *testing.T) {
+	var calls []string
+	mux := NewHandy()
+	mux.Use(func(Handler) Interceptor {
+		return &recorderInterceptor{name: "global", calls: &calls}
+	})
+
+	api := mux.Group("/api", func(Handler) Interceptor {
+		return &recorderInterceptor{name: "api", calls: &calls}
+	})
+
+	api.HandleFunc("GET", "/ping", func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+		calls = append(calls, "func")
+		return http.StatusOK
+	})
+
+	data := []struct {
+		uri      string
+		expected []string
+	}{
+		{
+			uri:      "/api/ping",
+			expected: []string{"global.Before", "api.Before", "func", "api.After", "global.After"},
+		},
+		{
+			uri:      "/api/missing",
+			expected: []string{"global.Before", "global.After"},
+		},
+	}
+
+	for i, item := range data {
+		calls = nil
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", item.uri, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if !reflect.DeepEqual(calls, item.expected) {
+			t.Errorf("Item %d: wrong calls. Expecting “%v”; found “%v”", i, item.expected, calls)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	mux := NewHandy()
+	mux.Handle("/uri/{x}", func() Handler {
+		return new(mockGetHandler)
+	})
+
+	mux.HandleFunc("POST", "/uri/{x}", func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+		return http.StatusCreated
+	})
+
+	expected := []Route{
+		{Pattern: "/uri/{x}", Methods: []string{"GET", "HEAD", "POST", "OPTIONS"}},
+	}
+
+	if routes := mux.Routes(); !reflect.DeepEqual(routes, expected) {
+		t.Errorf("Wrong routes. Expecting “%v”; found “%v”", expected, routes)
+	}
+
+	// Changing the listed routes must not change the ones in use
+	mux.Routes()[0].Methods[0] = "DELETE"
+
+	if routes := mux.Routes(); !reflect.DeepEqual(routes, expected) {
+		t.Errorf("Wrong routes after changing the list. Expecting “%v”; found “%v”", expected, routes)
+	}
+}
+
+func TestRemoveAndReplace(t *testing.T) {
+	mux := NewHandy()
+	mux.Handle("/uri", func() Handler {
+		return new(mockGetHandler)
+	})
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", "/uri", nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if status := serve(); status != http.StatusNoContent {
+		t.Errorf("Wrong status. Expecting “%d”; found “%d”", http.StatusNoContent, status)
+	}
+
+	err := mux.Replace("/uri", func() Handler {
+		return new(mockBodyHandler)
+	})
+
+	if err != nil {
+		t.Fatal("Cannot replace a valid route", err)
+	}
+
+	if status := serve(); status != http.StatusOK {
+		t.Errorf("Wrong status. Expecting “%d”; found “%d”", http.StatusOK, status)
+	}
+
+	if err := mux.Remove("/uri"); err != nil {
+		t.Fatal("Cannot remove a valid route", err)
+	}
+
+	if err := mux.Replace("/uri", func() Handler { return new(mockBodyHandler) }); err != ErrRouteNotFound {
+		t.Error("Replacing a missing route", err)
+	}
+
+	rt := mux.Router()
+	if err := rt.AppendRoute("/uri", func() Handler { return new(mockGetHandler) }); err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	if routes := mux.Routes(); len(routes) != 0 {
+		t.Errorf("Changing a copy of the router changed the one in use: %v", routes)
+	}
+
+	mux.SetRouter(rt)
+
+	if status := serve(); status != http.StatusNoContent {
+		t.Errorf("Wrong status. Expecting “%d”; found “%d”", http.StatusNoContent, status)
+	}
+}
+
+func TestConcurrentChanges(t *testing.T) {
+	mux := NewHandy()
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			mux.Handle(fmt.Sprintf("/uri/%d", i), func() Handler {
+				return new(mockGetHandler)
+			})
+		}
+
+		done <- true
+	}()
+
+	serve := func(i int) int {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", fmt.Sprintf("/uri/%d", i), nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	noMatchFunc := NoMatchFunc
+	defer func() {
+		NoMatchFunc = noMatchFunc
+	}()
+
+	NoMatchFunc = nil
+
+	// Each route is either not registered yet or already served
+	for i := 0; i < 100; i++ {
+		if status := serve(i); status != http.StatusNotFound && status != http.StatusNoContent {
+			t.Errorf("Item %d: wrong status while registering. Expecting “%d” or “%d”; found “%d”", i, http.StatusNotFound, http.StatusNoContent, status)
+		}
+	}
+
+	<-done
+
+	for i := 0; i < 100; i++ {
+		if status := serve(i); status != http.StatusNoContent {
+			t.Errorf("Item %d: wrong status. Expecting “%d”; found “%d”", i, http.StatusNoContent, status)
+		}
+	}
+}
+
+func TestRawPathAndCaseInsensitive(t *testing.T) {
+	var vars URIVars
+	f := func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+		vars = u
+		w.WriteHeader(http.StatusOK)
+		return http.StatusOK
+	}
+
+	data := []struct {
+		description     string
+		useRawPath      bool
+		caseInsensitive bool
+		uri             string
+		expectedStatus  int
+		expectedVars    URIVars
+	}{
+		{
+			description:    "it should split the decoded path",
+			uri:            "/users/a%2Fb/files",
+			expectedStatus: http.StatusOK,
+			expectedVars:   URIVars{"path": "users/a/b/files"},
+		},
+		{
+			description:    "it should keep encoded slashes in URI variables",
+			useRawPath:     true,
+			uri:            "/users/a%2Fb/files",
+			expectedStatus: http.StatusOK,
+			expectedVars:   URIVars{"name": "a/b"},
+		},
+		{
+			description:    "it should decode the segments",
+			useRawPath:     true,
+			uri:            "/users/a%20b/files",
+			expectedStatus: http.StatusOK,
+			expectedVars:   URIVars{"name": "a b"},
+		},
+		{
+			description:    "it should compare static segments with case",
+			uri:            "/USERS/a/files",
+			expectedStatus: http.StatusOK,
+			expectedVars:   URIVars{"path": "USERS/a/files"},
+		},
+		{
+			description:     "it should compare static segments ignoring case",
+			caseInsensitive: true,
+			uri:             "/USERS/a/Files",
+			expectedStatus:  http.StatusOK,
+			expectedVars:    URIVars{"name": "a"},
+		},
+	}
+
+	for i, item := range data {
+		mux := NewHandy()
+		mux.UseRawPath = item.useRawPath
+		mux.CaseInsensitive = item.caseInsensitive
+		mux.HandleFunc("GET", "/users/{name}/files", f)
+		mux.HandleFunc("GET", "/{path*}", f)
+
+		vars = nil
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", item.uri, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != item.expectedStatus {
+			t.Errorf("Item %d, “%s”: wrong status. Expecting “%d”; found “%d”", i, item.description, item.expectedStatus, w.Code)
+		}
+
+		if !reflect.DeepEqual(vars, item.expectedVars) {
+			t.Errorf("Item %d, “%s”: wrong URI variables. Expecting “%v”; found “%v”", i, item.description, item.expectedVars, vars)
+		}
+	}
+}
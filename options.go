@@ -0,0 +1,86 @@
+package handy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RouteOption customizes a route when it is registered
+type RouteOption func(*routeOptions)
+
+type routeOptions struct {
+	name         string
+	host         string
+	interceptors []InterceptorFactory
+	conditions   []condition
+}
+
+func newRouteOptions(options []RouteOption) *routeOptions {
+	o := new(routeOptions)
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// Name identifies the route, so its URL can be rebuilt with Handy.URL
+func Name(name string) RouteOption {
+	return func(o *routeOptions) {
+		o.name = name
+	}
+}
+
+// WithInterceptors wraps the interceptor chain of the route's handler with
+// the given interceptors
+func WithInterceptors(interceptors ...InterceptorFactory) RouteOption {
+	return func(o *routeOptions) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// Host restricts the route to requests for the given host, that can have
+// wildcards like the path (eg: "{tenant}.example.com") and be prefixed by a
+// scheme (eg: "https://api.example.com")
+func Host(host string) RouteOption {
+	return func(o *routeOptions) {
+		o.host = strings.TrimSpace(host)
+	}
+}
+
+// Header restricts the route to requests with the header set to the value.
+// With an empty value, the header only needs to be present
+func Header(name, value string) RouteOption {
+	return func(o *routeOptions) {
+		o.conditions = append(o.conditions, headerCondition{
+			name:  http.CanonicalHeaderKey(name),
+			value: value,
+		})
+	}
+}
+
+// Query restricts the route to requests with the query string parameter set
+// to the value. With an empty value, the parameter only needs to be present
+func Query(name, value string) RouteOption {
+	return func(o *routeOptions) {
+		o.conditions = append(o.conditions, queryCondition{name: name, value: value})
+	}
+}
+
+// ContentType restricts the route to requests whose body has one of the
+// media types, that can be ranges like "text/*". Requests to the path that
+// no route accepts are answered with 415 Unsupported Media Type
+func ContentType(mediaTypes ...string) RouteOption {
+	return func(o *routeOptions) {
+		o.conditions = append(o.conditions, contentTypeCondition{normalizeMediaTypes(mediaTypes)})
+	}
+}
+
+// Accept restricts the route to requests that accept one of the media types
+// as response (eg: "application/vnd.x.v2+json"). Requests to the path that no
+// route accepts are answered with 406 Not Acceptable
+func Accept(mediaTypes ...string) RouteOption {
+	return func(o *routeOptions) {
+		o.conditions = append(o.conditions, acceptCondition{normalizeMediaTypes(mediaTypes)})
+	}
+}
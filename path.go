@@ -0,0 +1,51 @@
+package handy
+
+import (
+	"net/http"
+	"path"
+)
+
+// PathPolicy defines what Handy does with request paths that are not in
+// their canonical form, that is, with empty, "." or ".." segments or with a
+// trailing slash. Paths served by a catch-all route, like the ones of a
+// mounted http.FileServer, keep their trailing slash
+type PathPolicy int
+
+const (
+	// PathLenient ignores the empty segments and matches the path as is
+	PathLenient PathPolicy = iota
+
+	// PathStrict only matches canonical paths; any other path is not found
+	PathStrict
+
+	// PathRedirect redirects to the canonical path with 301 (Moved
+	// Permanently), like http.ServeMux does
+	PathRedirect
+
+	// PathRedirectPermanent redirects to the canonical path with 308
+	// (Permanent Redirect), so clients keep the method and the body
+	PathRedirectPermanent
+)
+
+func (p PathPolicy) redirectStatus() int {
+	if p == PathRedirectPermanent {
+		return http.StatusPermanentRedirect
+	}
+
+	return http.StatusMovedPermanently
+}
+
+// cleanPath resolves the "." and ".." segments of the path and removes its
+// empty segments. Like in http.ServeMux, the trailing slash is kept
+func cleanPath(p string) string {
+	if p == "" || p[0] != '/' {
+		p = "/" + p
+	}
+
+	clean := path.Clean(p)
+	if p[len(p)-1] == '/' && clean != "/" {
+		clean += "/"
+	}
+
+	return clean
+}
@@ -0,0 +1,230 @@
+package handy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathPolicy(t *testing.T) {
+	data := []struct {
+		description      string
+		policy           PathPolicy
+		uri              string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			description:    "it should match a path with empty segments",
+			policy:         PathLenient,
+			uri:            "/a//b/",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			description:    "it should match a canonical path",
+			policy:         PathStrict,
+			uri:            "/a/b",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			description:    "it should not match a path with empty segments",
+			policy:         PathStrict,
+			uri:            "/a//b",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description:    "it should not match a path with trailing slash",
+			policy:         PathStrict,
+			uri:            "/a/b/",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description:      "it should redirect to the canonical path",
+			policy:           PathRedirect,
+			uri:              "/a/./c/../b/?x=1",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/a/b?x=1",
+		},
+		{
+			description:      "it should redirect keeping the method",
+			policy:           PathRedirectPermanent,
+			uri:              "/a//b",
+			expectedStatus:   http.StatusPermanentRedirect,
+			expectedLocation: "/a/b",
+		},
+		{
+			description:    "it should not redirect to a path that does not exist",
+			policy:         PathRedirect,
+			uri:            "/a/c/",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	noMatchFunc := NoMatchFunc
+	defer func() {
+		NoMatchFunc = noMatchFunc
+	}()
+
+	NoMatchFunc = nil
+
+	for i, item := range data {
+		mux := NewHandy()
+		mux.PathPolicy = item.policy
+		mux.Handle("/a/b", func() Handler {
+			return new(mockGetHandler)
+		})
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", item.uri, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != item.expectedStatus {
+			t.Errorf("Item %d, “%s”: wrong status. Expecting “%d”; found “%d”", i, item.description, item.expectedStatus, w.Code)
+		}
+
+		if location := w.Header().Get("Location"); location != item.expectedLocation {
+			t.Errorf("Item %d, “%s”: wrong location. Expecting “%s”; found “%s”", i, item.description, item.expectedLocation, location)
+		}
+	}
+}
+
+func TestPathPolicyCatchAll(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		description      string
+		uri              string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			description:      "it should let the mounted handler add the trailing slash",
+			uri:              "/static/dir",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "dir/",
+		},
+		{
+			description:    "it should keep the trailing slash of a catch-all route",
+			uri:            "/static/dir/",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:      "it should redirect to the canonical path keeping the trailing slash",
+			uri:              "/static//dir/",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/static/dir/",
+		},
+	}
+
+	mux := NewHandy()
+	mux.PathPolicy = PathRedirect
+	mux.Mount("/static", http.FileServer(http.Dir(root)))
+
+	for i, item := range data {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", item.uri, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != item.expectedStatus {
+			t.Errorf("Item %d, “%s”: wrong status. Expecting “%d”; found “%d”", i, item.description, item.expectedStatus, w.Code)
+		}
+
+		if location := w.Header().Get("Location"); location != item.expectedLocation {
+			t.Errorf("Item %d, “%s”: wrong location. Expecting “%s”; found “%s”", i, item.description, item.expectedLocation, location)
+		}
+	}
+}
+
+func TestPathPolicyRawPath(t *testing.T) {
+	data := []struct {
+		description      string
+		policy           PathPolicy
+		uri              string
+		expectedStatus   int
+		expectedLocation string
+		expectedName     string
+	}{
+		{
+			description:    "it should not take escaped slashes as empty segments",
+			policy:         PathRedirect,
+			uri:            "/files/a%2F%2Fb",
+			expectedStatus: http.StatusOK,
+			expectedName:   "a//b",
+		},
+		{
+			description:    "it should not take an escaped slash as the trailing slash",
+			policy:         PathStrict,
+			uri:            "/files/a%2F",
+			expectedStatus: http.StatusOK,
+			expectedName:   "a/",
+		},
+		{
+			description:      "it should redirect to the canonical escaped path",
+			policy:           PathRedirect,
+			uri:              "/files//a%2Fb",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/files/a%2Fb",
+		},
+		{
+			description:    "it should not match an escaped path with trailing slash",
+			policy:         PathStrict,
+			uri:            "/files/a%2Fb/",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	noMatchFunc := NoMatchFunc
+	defer func() {
+		NoMatchFunc = noMatchFunc
+	}()
+
+	NoMatchFunc = nil
+
+	for i, item := range data {
+		var name string
+		mux := NewHandy()
+		mux.PathPolicy = item.policy
+		mux.UseRawPath = true
+		mux.HandleFunc("GET", "/files/{name}", func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+			name = u["name"]
+			w.WriteHeader(http.StatusOK)
+			return http.StatusOK
+		})
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", item.uri, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != item.expectedStatus {
+			t.Errorf("Item %d, “%s”: wrong status. Expecting “%d”; found “%d”", i, item.description, item.expectedStatus, w.Code)
+		}
+
+		if location := w.Header().Get("Location"); location != item.expectedLocation {
+			t.Errorf("Item %d, “%s”: wrong location. Expecting “%s”; found “%s”", i, item.description, item.expectedLocation, location)
+		}
+
+		if name != item.expectedName {
+			t.Errorf("Item %d, “%s”: wrong name. Expecting “%s”; found “%s”", i, item.description, item.expectedName, name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package handy
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headResponseWriter discards the body written by a GET method that is
+// answering a HEAD request, keeping track of its size to report the correct
+// Content-Length
+type headResponseWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+func (w *headResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	w.length += len(b)
+	return len(b), nil
+}
+
+// flush sends the headers held back until the whole body was measured
+func (w *headResponseWriter) flush() {
+	if w.status == 0 {
+		return
+	}
+
+	noBody := w.status < http.StatusOK ||
+		w.status == http.StatusNoContent ||
+		w.status == http.StatusNotModified
+
+	if !noBody && w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.length))
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// StatusResponseWriter is the http.ResponseWriter given to the handlers. It
+// keeps track of what was already sent, so interceptors can tell if the
+// handler answered the request by itself
+type StatusResponseWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+// NewStatusResponseWriter wraps the http.ResponseWriter, unless it's already a
+// StatusResponseWriter
+func NewStatusResponseWriter(w http.ResponseWriter) *StatusResponseWriter {
+	if sw, ok := w.(*StatusResponseWriter); ok {
+		return sw
+	}
+
+	return &StatusResponseWriter{ResponseWriter: w}
+}
+
+func (w *StatusResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *StatusResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.length += n
+	return n, err
+}
+
+// Written tells if the status of the response was already sent
+func (w *StatusResponseWriter) Written() bool {
+	return w.status != 0
+}
+
+// Status returns the status sent, or zero if nothing was sent yet
+func (w *StatusResponseWriter) Status() int {
+	return w.status
+}
+
+// BytesWritten returns the size of the body sent so far
+func (w *StatusResponseWriter) BytesWritten() int {
+	return w.length
+}
+
+// Flush sends the buffered data to the client, if the wrapped
+// http.ResponseWriter supports it
+func (w *StatusResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, so http.ResponseController
+// can reach its features
+func (w *StatusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
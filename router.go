@@ -2,140 +2,862 @@ package handy
 
 import (
 	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
 )
 
 var (
-	ErrRouteNotFound      = errors.New("Router not found")
-	ErrRouteAlreadyExists = errors.New("Route already exists")
-	ErrCannotAppendRoute  = errors.New("Cannot append route")
-	ErrOnlyOneWildcard    = errors.New("Only one wildcard is allowed in this level")
+	ErrRouteNotFound        = errors.New("Router not found")
+	ErrRouteAlreadyExists   = errors.New("Route already exists")
+	ErrCannotAppendRoute    = errors.New("Cannot append route")
+	ErrOnlyOneWildcard      = errors.New("Only one catch-all wildcard is allowed in this level")
+	ErrMethodAlreadyExists  = errors.New("Method already handled by this route")
+	ErrCatchAllNotLast      = errors.New("Catch-all wildcard must be the last segment of the route")
+	ErrDifferentWildcards   = errors.New("Route already exists with different wildcard names")
+	ErrNameAlreadyExists    = errors.New("Route name already exists")
+	ErrUnknownName          = errors.New("Unknown route name")
+	ErrMissingURIVar        = errors.New("Missing URI variable")
+	ErrInvalidURIVar        = errors.New("URI variable does not satisfy its constraint")
+	ErrInvalidHost          = errors.New("Invalid host pattern")
+	ErrNotAcceptable        = errors.New("No route produces an acceptable response")
+	ErrUnsupportedMediaType = errors.New("No route accepts the media type of the request")
 )
 
+// Constraints are the named types that can be used to constrain URI
+// variables (eg: "/user/{id:int}"). Any other constraint is compiled as a
+// regular expression (eg: "/user/{id:[0-9]+}")
+var Constraints = map[string]string{
+	"int":   `[-+]?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"float": `[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
 type node struct {
-	name             string
+	name       string
+	endpoints  []*endpoint
+	isWildcard bool
+	parent     *node
+	children   map[string]*node
+	wildcards  []*node
+	catchAll   *node
+	constraint *regexp.Regexp
+	key        string
+	vars       []string
+}
+
+// endpoint holds the handlers of a route. A path can have many endpoints,
+// each one with different conditions on the request
+type endpoint struct {
+	conditions       []condition
+	key              string
+	names            []string
 	handler          Constructor
-	isWildcard       bool
-	hasChildWildcard bool
-	parent           *node
-	children         map[string]*node
-	wildcardName     string
+	handlerMethods   []string
+	interceptors     []InterceptorFactory
+	funcs            map[string]HandlerFunc
+	funcInterceptors map[string][]InterceptorFactory
+	methods          []string
+	headFromGet      bool
 }
 
+// Router is the route table used by Handy. It is not safe to change a
+// Router that is in use: Handy changes copies of it, replacing the one in use
+// atomically
 type Router struct {
-	root    *node
-	current *node
+	root  *node
+	hosts []*hostRoute
+	names map[string]*node
 }
 
 func NewRouter() *Router {
 	r := new(Router)
-	root := new(node)
-	root.children = make(map[string]*node)
-	r.root = root
-	r.current = r.root
+	r.root = newNode("", nil)
+	r.names = make(map[string]*node)
 	return r
 }
 
+func newNode(name string, parent *node) *node {
+	n := new(node)
+	n.name = name
+	n.parent = parent
+	n.children = make(map[string]*node)
+	return n
+}
+
+// newWildcard creates a wildcard node. Wildcards are identified by their
+// constraints only, as the names of the URI variables are resolved by the
+// route that was matched
+func newWildcard(l string, parent *node) (*node, error) {
+	constraint, err := compileConstraint(l)
+	if err != nil {
+		return nil, err
+	}
+
+	n := newNode(l, parent)
+	n.isWildcard = true
+	n.constraint = constraint
+	_, n.key, _ = parseWildcard(l)
+	return n, nil
+}
+
 func isWildcard(l string) bool {
 	return l[0] == '{' && l[len(l)-1] == '}'
 }
 
-func cleanWildcard(l string) string {
-	return l[1 : len(l)-1]
+// parseWildcard splits a wildcard in the name of the URI variable and its
+// constraint, detecting the greedy wildcards (eg: "{path*}"), that match all
+// the remaining segments of the URI
+func parseWildcard(l string) (name, constraint string, catchAll bool) {
+	name = l[1 : len(l)-1]
+	if i := strings.Index(name, ":"); i >= 0 {
+		name, constraint = name[:i], name[i+1:]
+	}
+
+	if len(name) > 1 && name[len(name)-1] == '*' {
+		name, catchAll = name[:len(name)-1], true
+	}
+
+	return
 }
 
-func (r *Router) nodeExists(n string) (*node, bool) {
-	v, ok := r.current.children[n]
-	if !ok && r.current.hasChildWildcard {
-		if isWildcard(n) {
-			n = cleanWildcard(n)
-			// looking for wildcard with the same name
-			v, ok = r.current.children[n]
-		}
+func isCatchAll(l string) bool {
+	if !isWildcard(l) {
+		return false
 	}
 
-	return v, ok
+	_, _, catchAll := parseWildcard(l)
+	return catchAll
 }
 
-func (r *Router) AppendRoute(uri string, h Constructor) error {
-	uri = strings.TrimSpace(uri)
+func cleanWildcard(l string) string {
+	name, _, _ := parseWildcard(l)
+	return name
+}
+
+// compileConstraint builds the expression that validates the values of a
+// wildcard, returning nil when there's no constraint
+func compileConstraint(l string) (*regexp.Regexp, error) {
+	_, constraint, _ := parseWildcard(l)
+	if constraint == "" {
+		return nil, nil
+	}
 
-	// Make sure we are not appending the root ("/"), otherwise remove final slash
-	if len(uri) > 1 && uri[len(uri)-1] == '/' {
-		uri = uri[:len(uri)-1]
+	if expr, ok := Constraints[constraint]; ok {
+		constraint = expr
 	}
 
-	// Should end at root node
-	defer func() {
-		r.current = r.root
-	}()
+	return regexp.Compile("^(?:" + constraint + ")$")
+}
+
+func (n *node) accepts(value string) bool {
+	return n.constraint == nil || n.constraint.MatchString(value)
+}
 
-	appended := false
-	tokens := strings.Split(uri, "/")
+// appendNode walks the tree creating the nodes needed by the given URI and
+// returns the last one, that will hold the handlers of the route, and the
+// names of the URI variables in the order they appear
+func (r *Router) appendNode(root *node, uri string) (*node, []string, error) {
+	tokens := splitPath(uri)
+	current := root
+	var vars []string
 	for i, v := range tokens {
-		if v == "" {
+		if !isWildcard(v) {
+			n, ok := current.children[v]
+			if !ok {
+				n = newNode(v, current)
+				current.children[v] = n
+			}
+
+			current = n
+			continue
+		}
+
+		name, constraint, catchAll := parseWildcard(v)
+		vars = append(vars, name)
+
+		if catchAll {
+			if i != len(tokens)-1 {
+				return nil, nil, ErrCatchAllNotLast
+			}
+
+			if current.catchAll == nil {
+				n, err := newWildcard(v, current)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				current.catchAll = n
+
+			} else if current.catchAll.key != constraint {
+				return nil, nil, ErrOnlyOneWildcard
+			}
+
+			current = current.catchAll
 			continue
 		}
 
-		if r.current.hasChildWildcard && !isWildcard(v) {
-			return ErrCannotAppendRoute
+		n := current.findWildcard(constraint)
+		if n == nil {
+			var err error
+			if n, err = newWildcard(v, current); err != nil {
+				return nil, nil, err
+			}
+
+			current.appendWildcard(n)
+		}
+
+		current = n
+	}
+
+	if current == root {
+		return nil, nil, ErrCannotAppendRoute
+	}
+
+	return current, vars, nil
+}
+
+func splitPath(uri string) []string {
+	var tokens []string
+	for _, v := range strings.Split(strings.TrimSpace(uri), "/") {
+		if v != "" {
+			tokens = append(tokens, v)
 		}
+	}
+
+	return tokens
+}
 
-		if n, ok := r.nodeExists(v); ok {
-			if i == len(tokens)-1 && n.handler != nil {
-				return ErrRouteAlreadyExists
+// findNode looks for the node of the pattern without creating it. Wildcards
+// are compared by their constraints, not by their names
+func findNode(root *node, uri string) *node {
+	current := root
+	for _, v := range splitPath(uri) {
+		if !isWildcard(v) {
+			current = current.children[v]
 
-			} else if i == len(tokens)-1 {
-				n.handler = h
+		} else if _, constraint, catchAll := parseWildcard(v); catchAll {
+			current = current.catchAll
+			if current != nil && current.key != constraint {
 				return nil
 			}
 
-			r.current = n
-			appended = true
-			continue
+		} else {
+			current = current.findWildcard(constraint)
+		}
+
+		if current == nil {
+			return nil
+		}
+	}
+
+	return current
+}
+
+func (n *node) findWildcard(constraint string) *node {
+	for _, w := range n.wildcards {
+		if w.key == constraint {
+			return w
+		}
+	}
+
+	return nil
+}
+
+// appendWildcard keeps the wildcards without constraints at the end, so
+// that the constrained ones have the chance to match first
+func (n *node) appendWildcard(w *node) {
+	i := len(n.wildcards)
+	if w.constraint != nil {
+		for i > 0 && n.wildcards[i-1].constraint == nil {
+			i--
+		}
+	}
+
+	n.wildcards = append(n.wildcards, nil)
+	copy(n.wildcards[i+1:], n.wildcards[i:])
+	n.wildcards[i] = w
+}
+
+// setVars defines the names of the URI variables of the route. All the
+// handlers of a route must agree on them
+func (n *node) setVars(vars []string) error {
+	if n.hasRoute() && !sameVars(n.vars, vars) {
+		return ErrDifferentWildcards
+	}
+
+	n.vars = vars
+	return nil
+}
+
+func sameVars(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
+	}
 
-		n := new(node)
-		n.children = make(map[string]*node)
+	return true
+}
 
-		// only one child wildcard per node
+// patternVars returns the names of the URI variables of the pattern, in the
+// order they appear
+func patternVars(uri string) []string {
+	var vars []string
+	for _, v := range splitPath(uri) {
 		if isWildcard(v) {
-			if r.current.hasChildWildcard {
-				return ErrOnlyOneWildcard
+			name, _, _ := parseWildcard(v)
+			vars = append(vars, name)
+		}
+	}
+
+	return vars
+}
+
+func (r *Router) AppendRoute(uri string, h Constructor, options ...RouteOption) error {
+	o := newRouteOptions(options)
+	if _, ok := r.names[o.name]; ok && o.name != "" {
+		return ErrNameAlreadyExists
+	}
+
+	root, err := r.hostRoot(o.host)
+	if err != nil {
+		return err
+	}
+
+	n, vars, err := r.appendNode(root, uri)
+	if err != nil {
+		return err
+	}
+
+	e, isNew := n.endpoint(o.conditions)
+	if e.handler != nil {
+		return ErrRouteAlreadyExists
+	}
+
+	if err := n.setVars(vars); err != nil {
+		return err
+	}
+
+	// A method registered with AppendRouteFunc cannot also be implemented
+	// by the handler
+	methods := handlerMethods(h)
+	for method := range e.funcs {
+		if containsMethod(methods, method) {
+			return ErrMethodAlreadyExists
+		}
+	}
+
+	e.handler, e.handlerMethods = h, methods
+	e.interceptors = o.interceptors
+	e.updateMethods()
+	if isNew {
+		n.endpoints = append(n.endpoints, e)
+	}
+
+	r.setName(o.name, n, e)
+	return nil
+}
+
+func (r *Router) AppendRouteFunc(method, uri string, f HandlerFunc, options ...RouteOption) error {
+	o := newRouteOptions(options)
+	if _, ok := r.names[o.name]; ok && o.name != "" {
+		return ErrNameAlreadyExists
+	}
+
+	root, err := r.hostRoot(o.host)
+	if err != nil {
+		return err
+	}
+
+	n, vars, err := r.appendNode(root, uri)
+	if err != nil {
+		return err
+	}
+
+	e, isNew := n.endpoint(o.conditions)
+	method = strings.ToUpper(method)
+	if _, ok := e.funcs[method]; ok {
+		return ErrMethodAlreadyExists
+	}
+
+	if containsMethod(e.handlerMethods, method) {
+		return ErrMethodAlreadyExists
+	}
+
+	if err := n.setVars(vars); err != nil {
+		return err
+	}
+
+	if e.funcs == nil {
+		e.funcs = make(map[string]HandlerFunc)
+		e.funcInterceptors = make(map[string][]InterceptorFactory)
+	}
+
+	e.funcs[method] = f
+	e.funcInterceptors[method] = o.interceptors
+	e.updateMethods()
+	if isNew {
+		n.endpoints = append(n.endpoints, e)
+	}
+
+	r.setName(o.name, n, e)
+	return nil
+}
+
+// endpoint returns the endpoint of the node with the same conditions, or a
+// new one that must be appended to the node when it's ready
+func (n *node) endpoint(conditions []condition) (*endpoint, bool) {
+	key := conditionsKey(conditions)
+	for _, e := range n.endpoints {
+		if e.key == key {
+			return e, false
+		}
+	}
+
+	return &endpoint{conditions: conditions, key: key}, true
+}
+
+// RemoveRoute unregisters the handlers of the route whose host and
+// conditions are exactly the ones of the options, discarding the nodes that
+// are not needed anymore. ErrRouteNotFound is returned if there's none
+func (r *Router) RemoveRoute(uri string, options ...RouteOption) error {
+	o := newRouteOptions(options)
+	root := r.findHostRoot(o.host)
+	if root == nil {
+		return ErrRouteNotFound
+	}
+
+	n := findNode(root, uri)
+	if n == nil {
+		return ErrRouteNotFound
+	}
+
+	i := n.endpointIndex(conditionsKey(o.conditions))
+	if i < 0 {
+		return ErrRouteNotFound
+	}
+
+	for _, name := range n.endpoints[i].names {
+		delete(r.names, name)
+	}
+
+	n.endpoints = append(n.endpoints[:i:i], n.endpoints[i+1:]...)
+	if n.hasRoute() {
+		return nil
+	}
+
+	for name, named := range r.names {
+		if named == n {
+			delete(r.names, name)
+		}
+	}
+
+	n.endpoints = nil
+	n.vars = nil
+
+	for n != root && !n.hasRoute() && n.isEmpty() {
+		n.parent.removeChild(n)
+		n = n.parent
+	}
+
+	if root != r.root && root.isEmpty() {
+		for i, h := range r.hosts {
+			if h.root == root {
+				r.hosts = append(r.hosts[:i:i], r.hosts[i+1:]...)
+				break
 			}
+		}
+	}
+
+	return nil
+}
+
+// ReplaceRoute swaps the handlers of the route with the conditions of the
+// options for the given one. The interceptors and the name of the route are
+// kept, unless new ones are given. Nothing is changed if there's an error
+func (r *Router) ReplaceRoute(uri string, h Constructor, options ...RouteOption) error {
+	o := newRouteOptions(options)
+	root := r.findHostRoot(o.host)
+	if root == nil {
+		return ErrRouteNotFound
+	}
+
+	n := findNode(root, uri)
+	if n == nil {
+		return ErrRouteNotFound
+	}
+
+	i := n.endpointIndex(conditionsKey(o.conditions))
+	if i < 0 {
+		return ErrRouteNotFound
+	}
+
+	old := n.endpoints[i]
+	if _, ok := r.names[o.name]; ok && o.name != "" && !containsMethod(old.names, o.name) {
+		return ErrNameAlreadyExists
+	}
 
-			n.isWildcard = true
-			r.current.wildcardName = v
-			r.current.hasChildWildcard = true
+	vars := patternVars(uri)
+	if len(n.endpoints) > 1 && !sameVars(n.vars, vars) {
+		return ErrDifferentWildcards
+	}
+
+	e := &endpoint{
+		conditions:     old.conditions,
+		key:            old.key,
+		names:          old.names,
+		handler:        h,
+		handlerMethods: handlerMethods(h),
+		interceptors:   old.interceptors,
+	}
+
+	if len(o.interceptors) > 0 {
+		e.interceptors = o.interceptors
+	}
+
+	if o.name != "" {
+		for _, name := range old.names {
+			delete(r.names, name)
 		}
 
-		n.name = v
-		n.parent = r.current
-		r.current.children[n.name] = n
-		r.current = n
-		appended = true
+		e.names = nil
+		r.setName(o.name, n, e)
 	}
 
-	if r.current != r.root {
-		r.current.handler = h
+	e.updateMethods()
+	n.endpoints[i] = e
+	n.vars = vars
+	return nil
+}
+
+// endpointIndex returns the position of the endpoint with the conditions of
+// the key, or -1 if there's none
+func (n *node) endpointIndex(key string) int {
+	for i, e := range n.endpoints {
+		if e.key == key {
+			return i
+		}
 	}
 
-	if appended == false {
-		return ErrCannotAppendRoute
+	return -1
+}
+
+// findHostRoot returns the root of the routes of the host without creating
+// it, or nil if there's no route for the host
+func (r *Router) findHostRoot(host string) *node {
+	if host == "" {
+		return r.root
+	}
+
+	for _, h := range r.hosts {
+		if h.pattern == host {
+			return h.root
+		}
 	}
 
 	return nil
+}
+
+// hostRoot returns the root of the routes of the host, creating it if
+// needed. Without a host, the root of the host-less routes is returned
+func (r *Router) hostRoot(host string) (*node, error) {
+	if host == "" {
+		return r.root, nil
+	}
+
+	for _, h := range r.hosts {
+		if h.pattern == host {
+			return h.root, nil
+		}
+	}
 
+	h, err := newHostRoute(host)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the most specific hosts first, in the order they were added
+	i := len(r.hosts)
+	for i > 0 && r.hosts[i-1].specificity() < h.specificity() {
+		i--
+	}
+
+	r.hosts = append(r.hosts, nil)
+	copy(r.hosts[i+1:], r.hosts[i:])
+	r.hosts[i] = h
+	return h.root, nil
+}
+
+func (n *node) isEmpty() bool {
+	return len(n.children) == 0 && len(n.wildcards) == 0 && n.catchAll == nil
+}
+
+func (n *node) removeChild(child *node) {
+	switch {
+	case child == n.catchAll:
+		n.catchAll = nil
+
+	case child.isWildcard:
+		for i, w := range n.wildcards {
+			if w == child {
+				n.wildcards = append(n.wildcards[:i:i], n.wildcards[i+1:]...)
+				break
+			}
+		}
+
+	default:
+		delete(n.children, child.name)
+	}
+}
+
+// Clone copies the whole route table, so it can be changed without
+// affecting the requests using the original one
+func (r *Router) Clone() *Router {
+	clones := make(map[*node]*node)
+	c := new(Router)
+	c.root = r.root.clone(nil, clones)
+	for _, h := range r.hosts {
+		hc := new(hostRoute)
+		*hc = *h
+		hc.root = h.root.clone(nil, clones)
+		c.hosts = append(c.hosts, hc)
+	}
+
+	c.names = make(map[string]*node, len(r.names))
+	for name, n := range r.names {
+		c.names[name] = clones[n]
+	}
+
+	return c
+}
+
+func (n *node) clone(parent *node, clones map[*node]*node) *node {
+	c := new(node)
+	*c = *n
+	c.parent = parent
+	clones[n] = c
+
+	c.children = make(map[string]*node, len(n.children))
+	for name, child := range n.children {
+		c.children[name] = child.clone(c, clones)
+	}
+
+	c.wildcards = nil
+	for _, w := range n.wildcards {
+		c.wildcards = append(c.wildcards, w.clone(c, clones))
+	}
+
+	if n.catchAll != nil {
+		c.catchAll = n.catchAll.clone(c, clones)
+	}
+
+	c.endpoints = nil
+	for _, e := range n.endpoints {
+		c.endpoints = append(c.endpoints, e.clone())
+	}
+
+	return c
+}
+
+func (e *endpoint) clone() *endpoint {
+	c := new(endpoint)
+	*c = *e
+	c.names = append([]string(nil), e.names...)
+	if e.funcs != nil {
+		c.funcs = make(map[string]HandlerFunc, len(e.funcs))
+		c.funcInterceptors = make(map[string][]InterceptorFactory, len(e.funcs))
+		for method, f := range e.funcs {
+			c.funcs[method] = f
+			c.funcInterceptors[method] = e.funcInterceptors[method]
+		}
+	}
+
+	return c
+}
+
+func (r *Router) setName(name string, n *node, e *endpoint) {
+	if name != "" {
+		r.names[name] = n
+		e.names = append(e.names, name)
+	}
+}
+
+// URL rebuilds the path of the route with the given name, replacing its
+// wildcards with the escaped URI variables
+func (r *Router) URL(name string, vars URIVars) (string, error) {
+	leaf, ok := r.names[name]
+	if !ok {
+		return "", ErrUnknownName
+	}
+
+	var path []*node
+	for n := leaf; n.parent != nil; n = n.parent {
+		path = append(path, n)
+	}
+
+	var segments []string
+	wildcard := 0
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		if !n.isWildcard {
+			segments = append(segments, url.PathEscape(n.name))
+			continue
+		}
+
+		value, ok := vars[leaf.vars[wildcard]]
+		wildcard++
+
+		if !ok {
+			return "", ErrMissingURIVar
+		}
+
+		if !n.accepts(value) {
+			return "", ErrInvalidURIVar
+		}
+
+		if isCatchAll(n.name) {
+			// the catch-all keeps its slashes, escaping each segment
+			var parts []string
+			for _, part := range strings.Split(value, "/") {
+				parts = append(parts, url.PathEscape(part))
+			}
+
+			value = strings.Join(parts, "/")
+
+		} else {
+			value = url.PathEscape(value)
+		}
+
+		segments = append(segments, value)
+	}
+
+	return "/" + strings.Join(segments, "/"), nil
+}
+
+// Walk calls the function for every route of the router, in the order they
+// are tried by Match, stopping at the first error. The patterns of the routes
+// restricted to a host are prefixed by it (eg: "{tenant}.example.com/users")
+func (r *Router) Walk(f func(pattern string, c Constructor, methods []string) error) error {
+	for _, h := range r.hosts {
+		if err := h.root.walk(h.pattern, f); err != nil {
+			return err
+		}
+	}
+
+	return r.root.walk("", f)
+}
+
+func (n *node) walk(prefix string, f func(string, Constructor, []string) error) error {
+	for _, e := range n.endpoints {
+		if err := f(prefix+n.pattern(), e.handler, append([]string(nil), e.methods...)); err != nil {
+			return err
+		}
+	}
+
+	var names []string
+	for name := range n.children {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	children := make([]*node, 0, len(names)+len(n.wildcards)+1)
+	for _, name := range names {
+		children = append(children, n.children[name])
+	}
+
+	children = append(children, n.wildcards...)
+	if n.catchAll != nil {
+		children = append(children, n.catchAll)
+	}
+
+	for _, child := range children {
+		if err := child.walk(prefix, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pattern rebuilds the pattern of the route, with the wildcard names used
+// when it was registered
+func (n *node) pattern() string {
+	var segments []string
+	wildcard := len(n.vars)
+	for current := n; current.parent != nil; current = current.parent {
+		if !current.isWildcard {
+			segments = append(segments, current.name)
+			continue
+		}
+
+		wildcard--
+		name := n.vars[wildcard]
+		if isCatchAll(current.name) {
+			name += "*"
+		}
+
+		if current.key != "" {
+			name += ":" + current.key
+		}
+
+		segments = append(segments, "{"+name+"}")
+	}
+
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// updateMethods caches the HTTP methods served by the route, so they don't
+// need to be found out on every request
+func (e *endpoint) updateMethods() {
+	methods := append([]string(nil), e.handlerMethods...)
+
+	var funcs []string
+	for method := range e.funcs {
+		funcs = append(funcs, method)
+	}
+
+	sort.Strings(funcs)
+	methods = append(methods, funcs...)
+
+	// HEAD falls back to GET when it isn't served by itself
+	e.headFromGet = containsMethod(methods, "GET") && !containsMethod(methods, "HEAD")
+
+	// OPTIONS is always answered, by the DefaultHandler if no one else does
+	e.methods = nil
+	for _, method := range append(methods, "OPTIONS") {
+		if !containsMethod(e.methods, method) {
+			e.methods = append(e.methods, method)
+		}
+
+		if method == "GET" && e.headFromGet {
+			e.methods = append(e.methods, "HEAD")
+		}
+	}
 }
 
-func (n *node) findChild(name string) *node {
-	v, ok := n.children[name]
-	if !ok && n.hasChildWildcard {
-		// looking for wildcard
-		v = n.children[n.wildcardName]
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
 	}
 
-	return v
+	return false
 }
 
 type URIVars map[string]string
@@ -143,36 +865,224 @@ type URIVars map[string]string
 type RouteMatch struct {
 	URIVars URIVars
 	Handler Constructor
+	Funcs   map[string]HandlerFunc
+	Methods []string
+
+	// endpoint is shared with the router in use, so its state is never
+	// given to the callers
+	endpoint *endpoint
+	catchAll bool
 }
 
-// This method rebuilds a route based on a given URI
+// This method rebuilds a route based on a given URI. Routes with conditions
+// on the request are not matched by it
 func (r *Router) Match(uri string) (*RouteMatch, error) {
-	rt := new(RouteMatch)
-	rt.URIVars = make(URIVars)
+	return exported(r.match(nil, "", "", splitPath(uri), false))
+}
+
+// MatchRequest finds the route for the request, considering its host and the
+// conditions of the routes. When the path matches but the request doesn't
+// satisfy the conditions, ErrNotAcceptable or ErrUnsupportedMediaType may be
+// returned
+func (r *Router) MatchRequest(req *http.Request) (*RouteMatch, error) {
+	scheme, host := requestHost(req)
+	return exported(r.match(req, scheme, host, splitPath(req.URL.Path), false))
+}
+
+// exported fills the fields of the route match with copies of the state of
+// its endpoint, that can be changed by the caller
+func exported(rt *RouteMatch, err error) (*RouteMatch, error) {
+	if rt.endpoint == nil {
+		return rt, err
+	}
+
+	if rt.endpoint.funcs != nil {
+		rt.Funcs = make(map[string]HandlerFunc, len(rt.endpoint.funcs))
+		for method, f := range rt.endpoint.funcs {
+			rt.Funcs[method] = f
+		}
+	}
+
+	rt.Methods = append([]string(nil), rt.endpoint.methods...)
+	return rt, err
+}
+
+// match finds the route for the already split (and unescaped) path. The
+// routes of a host are tried first, falling back to the host-less ones. With
+// foldCase, static segments are compared ignoring case
+func (r *Router) match(req *http.Request, scheme, host string, segments []string, foldCase bool) (*RouteMatch, error) {
+	var failure error = ErrRouteNotFound
+	if host != "" {
+		for _, h := range r.hosts {
+			hostValues, ok := h.match(scheme, host)
+			if !ok {
+				continue
+			}
+
+			current, values := h.root.match(segments, nil, foldCase)
+			if current == nil {
+				continue
+			}
+
+			e, err := current.selectEndpoint(req)
+			if err != nil {
+				failure = worseError(failure, err)
+				continue
+			}
+
+			rt := newRouteMatch(current, e, values)
+			for i, name := range h.vars {
+				if _, ok := rt.URIVars[name]; !ok {
+					rt.URIVars[name] = hostValues[i]
+				}
+			}
+
+			return rt, nil
+		}
+	}
+
+	if current, values := r.root.match(segments, nil, foldCase); current != nil {
+		e, err := current.selectEndpoint(req)
+		if err == nil {
+			return newRouteMatch(current, e, values), nil
+		}
 
-	current := r.current
-	uri = strings.TrimSpace(uri)
-	for _, v := range strings.Split(uri, "/") {
-		if v == "" {
+		failure = worseError(failure, err)
+	}
+
+	return &RouteMatch{URIVars: make(URIVars)}, failure
+}
+
+// selectEndpoint returns the endpoint whose conditions are satisfied by the
+// request that the request prefers: the one with the media type of higher
+// quality in its Accept header, then the one with more conditions matched
+// explicitly or, between equals, the one that assumes less about the request.
+// Without a request, only the endpoints without conditions are considered
+func (n *node) selectEndpoint(req *http.Request) (*endpoint, error) {
+	// The response of a route without an Accept condition can be of any
+	// type, that is as acceptable as "*/*" is to the request
+	unknownQuality := 1.0
+	if req != nil && len(req.Header["Accept"]) > 0 {
+		unknownQuality, _ = acceptQuality(ParseAccept(strings.Join(req.Header["Accept"], ",")))
+	}
+
+	var failure error = ErrRouteNotFound
+	var best *endpoint
+	bestQuality, bestExact := 0.0, 0
+	for _, e := range n.endpoints {
+		satisfied, negotiated, quality, exact := true, false, 1.0, 0
+		for _, c := range e.conditions {
+			if req == nil {
+				satisfied = false
+				break
+			}
+
+			q, isExact := c.match(req)
+			if q <= 0 {
+				failure = worseError(failure, c.err())
+				satisfied = false
+				break
+			}
+
+			if _, ok := c.(acceptCondition); ok {
+				negotiated = true
+			}
+
+			quality *= q
+			if isExact {
+				exact++
+			}
+		}
+
+		if !satisfied {
 			continue
 		}
 
-		n := current.findChild(v)
-		if n == nil {
-			return rt, ErrRouteNotFound
+		if !negotiated {
+			quality *= unknownQuality
 		}
 
-		if n.isWildcard {
-			rt.URIVars[cleanWildcard(n.name)] = v
+		if best == nil || quality > bestQuality ||
+			(quality == bestQuality && exact > bestExact) ||
+			(quality == bestQuality && exact == bestExact && len(e.conditions) < len(best.conditions)) {
+			best, bestQuality, bestExact = e, quality, exact
 		}
+	}
 
-		current = n
+	if best == nil {
+		return nil, failure
 	}
 
-	if current.handler == nil {
-		return rt, ErrRouteNotFound
+	return best, nil
+}
+
+func newRouteMatch(n *node, e *endpoint, values []string) *RouteMatch {
+	rt := new(RouteMatch)
+	rt.URIVars = make(URIVars)
+	for i, name := range n.vars {
+		rt.URIVars[name] = values[i]
 	}
 
-	rt.Handler = current.handler
-	return rt, nil
+	rt.Handler = e.handler
+	rt.endpoint = e
+	rt.catchAll = n.parent != nil && n.parent.catchAll == n
+	return rt
+}
+
+// match looks for the node with a route for the given segments, returning it
+// with the values of the wildcards in the path. Static segments are
+// preferred, backtracking to the single segment wildcards when they lead
+// nowhere; the catch-all wildcard, if any, is the last option and takes all
+// the remaining segments
+func (n *node) match(segments []string, values []string, foldCase bool) (*node, []string) {
+	if len(segments) == 0 && n.hasRoute() {
+		return n, values
+	}
+
+	if len(segments) > 0 {
+		if child, ok := n.children[segments[0]]; ok {
+			if found, v := child.match(segments[1:], values, foldCase); found != nil {
+				return found, v
+			}
+		}
+
+		if foldCase {
+			// Children that differ only by case are tried in order, so the
+			// same one is always chosen
+			var names []string
+			for name := range n.children {
+				if name != segments[0] && strings.EqualFold(name, segments[0]) {
+					names = append(names, name)
+				}
+			}
+
+			sort.Strings(names)
+			for _, name := range names {
+				if found, v := n.children[name].match(segments[1:], values, foldCase); found != nil {
+					return found, v
+				}
+			}
+		}
+
+		for _, child := range n.wildcards {
+			if child.accepts(segments[0]) {
+				if found, v := child.match(segments[1:], append(values, segments[0]), foldCase); found != nil {
+					return found, v
+				}
+			}
+		}
+	}
+
+	if n.catchAll != nil && n.catchAll.hasRoute() {
+		value := strings.Join(segments, "/")
+		if n.catchAll.accepts(value) {
+			return n.catchAll, append(values, value)
+		}
+	}
+
+	return nil, nil
+}
+
+func (n *node) hasRoute() bool {
+	return len(n.endpoints) > 0
 }
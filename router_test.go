@@ -1,6 +1,12 @@
 package handy
 
-import "testing"
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 func TestAppendRoute(t *testing.T) {
 	rt := NewRouter()
@@ -137,3 +143,446 @@ func TestMultipleWildCards(t *testing.T) {
 
 	t.Log(route.URIVars)
 }
+
+func TestAppendRouteFunc(t *testing.T) {
+	rt := NewRouter()
+	f := func(http.ResponseWriter, *http.Request, URIVars) int { return http.StatusOK }
+
+	err := rt.AppendRouteFunc("GET", "/test/{x}", f)
+	if err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	err = rt.AppendRouteFunc("post", "/test/{x}", f)
+	if err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	err = rt.AppendRouteFunc("GET", "/test/{x}", f)
+	if err != ErrMethodAlreadyExists {
+		t.Fatal("Appending the same method twice", err)
+	}
+
+	// The handler does not override Get nor Post, so it can coexist
+	err = rt.AppendRoute("/test/{x}", func() Handler { return new(DefaultHandler) })
+	if err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	err = rt.AppendRoute("/test/{x}/y", func() Handler { return new(mockHandler) })
+	if err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	err = rt.AppendRouteFunc("PUT", "/test/{x}/y", f)
+	if err != ErrMethodAlreadyExists {
+		t.Fatal("Appending a method implemented by the handler", err)
+	}
+
+	err = rt.AppendRouteFunc("PATCH", "/test/{x}/z", f)
+	if err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	err = rt.AppendRoute("/test/{x}/z", func() Handler { return new(mockHandler) })
+	if err != ErrMethodAlreadyExists {
+		t.Fatal("Appending a handler that implements a registered method", err)
+	}
+
+	route, err := rt.Match("/test/foo")
+	if err != nil {
+		t.Fatal("Cannot find a valid route;", err)
+	}
+
+	if route.Handler == nil || len(route.Funcs) != 2 {
+		t.Fatalf("Wrong route found: %#v", route)
+	}
+
+	// Changing the match must not change the route
+	delete(route.Funcs, "POST")
+	route.Methods[0] = "TRACE"
+
+	if route, _ = rt.Match("/test/foo"); len(route.Funcs) != 2 || route.Methods[0] == "TRACE" {
+		t.Fatalf("Route changed through its match: %#v", route)
+	}
+}
+
+func TestAppendRouteBuildsHandlerOnce(t *testing.T) {
+	rt := NewRouter()
+	f := func(w http.ResponseWriter, r *http.Request, u URIVars) int {
+		return http.StatusOK
+	}
+
+	for _, method := range []string{"POST", "PUT", "DELETE"} {
+		if err := rt.AppendRouteFunc(method, "/test", f); err != nil {
+			t.Fatal("Cannot append a valid route;", err)
+		}
+	}
+
+	calls := 0
+	err := rt.AppendRoute("/test", func() Handler {
+		calls++
+		return new(mockGetHandler)
+	})
+
+	if err != nil {
+		t.Fatal("Cannot append a valid route;", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Wrong number of handlers built. Expecting 1; found %d", calls)
+	}
+}
+
+func TestMatchFoldCase(t *testing.T) {
+	rt := NewRouter()
+	for _, uri := range []string{"/users/{lower}", "/Users/{upper}"} {
+		if err := rt.AppendRoute(uri, func() Handler { return new(mockGetHandler) }); err != nil {
+			t.Fatal("Cannot append a valid route;", err)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		route, err := rt.match(nil, "", "", splitPath("/USERS/1"), true)
+		if err != nil {
+			t.Fatal("Cannot find a valid route;", err)
+		}
+
+		if _, ok := route.URIVars["upper"]; !ok {
+			t.Fatalf("Wrong route found: %v", route.URIVars)
+		}
+	}
+}
+
+func TestCatchAll(t *testing.T) {
+	rt := NewRouter()
+	h := new(DefaultHandler)
+
+	for _, uri := range []string{"/files/{path*}", "/files/static/readme", "/files/{name}/info"} {
+		if err := rt.AppendRoute(uri, func() Handler { return h }); err != nil {
+			t.Fatal("Cannot append a valid route", uri, err)
+		}
+	}
+
+	err := rt.AppendRoute("/files/{path*}/info", func() Handler { return h })
+	if err != ErrCatchAllNotLast {
+		t.Fatal("Appending a catch-all that is not the last segment", err)
+	}
+
+	err = rt.AppendRoute("/files/{other*}", func() Handler { return h })
+	if err != ErrRouteAlreadyExists {
+		t.Fatal("Appending the same catch-all twice", err)
+	}
+
+	err = rt.AppendRoute("/files/{other*:[a-z]+}", func() Handler { return h })
+	if err != ErrOnlyOneWildcard {
+		t.Fatal("Appending two catch-alls in the same level", err)
+	}
+
+	data := []struct {
+		uri      string
+		expected URIVars
+	}{
+		{uri: "/files/a/b/c.txt", expected: URIVars{"path": "a/b/c.txt"}},
+		{uri: "/files/a/info", expected: URIVars{"name": "a"}},
+		{uri: "/files/a/info/more", expected: URIVars{"path": "a/info/more"}},
+		{uri: "/files/static/readme", expected: URIVars{}},
+		{uri: "/files/static/other", expected: URIVars{"path": "static/other"}},
+		{uri: "/files", expected: URIVars{"path": ""}},
+	}
+
+	for i, item := range data {
+		route, err := rt.Match(item.uri)
+		if err != nil {
+			t.Errorf("Item %d: cannot find a valid route “%s”; %s", i, item.uri, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(route.URIVars, item.expected) {
+			t.Errorf("Item %d: wrong URI variables. Expecting “%v”; found “%v”", i, item.expected, route.URIVars)
+		}
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	rt := NewRouter()
+	h := new(DefaultHandler)
+
+	routes := []string{"/user/{id:int}", "/user/{id:int}/machine/{ip:[0-9.]+}", "/tag/{name:[a-z]+}", "/tag/{path*}"}
+	for _, uri := range routes {
+		if err := rt.AppendRoute(uri, func() Handler { return h }); err != nil {
+			t.Fatal("Cannot append a valid route", uri, err)
+		}
+	}
+
+	err := rt.AppendRoute("/invalid/{id:[0-9}", func() Handler { return h })
+	if err == nil {
+		t.Fatal("Appending a route with an invalid constraint")
+	}
+
+	data := []struct {
+		uri      string
+		expected URIVars
+	}{
+		{uri: "/user/17", expected: URIVars{"id": "17"}},
+		{uri: "/user/-17", expected: URIVars{"id": "-17"}},
+		{uri: "/user/abc"},
+		{uri: "/user/17/machine/192.168.0.1", expected: URIVars{"id": "17", "ip": "192.168.0.1"}},
+		{uri: "/user/17/machine/localhost"},
+		{uri: "/tag/golang", expected: URIVars{"name": "golang"}},
+		{uri: "/tag/Go", expected: URIVars{"path": "Go"}},
+	}
+
+	for i, item := range data {
+		route, err := rt.Match(item.uri)
+		if item.expected == nil {
+			if err != ErrRouteNotFound {
+				t.Errorf("Item %d: matching an invalid route “%s”", i, item.uri)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Item %d: cannot find a valid route “%s”; %s", i, item.uri, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(route.URIVars, item.expected) {
+			t.Errorf("Item %d: wrong URI variables. Expecting “%v”; found “%v”", i, item.expected, route.URIVars)
+		}
+	}
+}
+
+func TestStaticAndWildcardSiblings(t *testing.T) {
+	rt := NewRouter()
+	h := new(DefaultHandler)
+
+	routes := []string{"/users/{id}", "/users/me", "/users/{id}/friends", "/users/me/settings"}
+	for _, uri := range routes {
+		if err := rt.AppendRoute(uri, func() Handler { return h }); err != nil {
+			t.Fatal("Cannot append a valid route", uri, err)
+		}
+	}
+
+	data := []struct {
+		uri      string
+		expected URIVars
+	}{
+		{uri: "/users/me", expected: URIVars{}},
+		{uri: "/users/17", expected: URIVars{"id": "17"}},
+		{uri: "/users/me/settings", expected: URIVars{}},
+		{uri: "/users/me/friends", expected: URIVars{"id": "me"}},
+		{uri: "/users/17/settings"},
+	}
+
+	for i, item := range data {
+		route, err := rt.Match(item.uri)
+		if item.expected == nil {
+			if err != ErrRouteNotFound {
+				t.Errorf("Item %d: matching an invalid route “%s”", i, item.uri)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Item %d: cannot find a valid route “%s”; %s", i, item.uri, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(route.URIVars, item.expected) {
+			t.Errorf("Item %d: wrong URI variables. Expecting “%v”; found “%v”", i, item.expected, route.URIVars)
+		}
+	}
+}
+
+func TestWildcardsWithDifferentNames(t *testing.T) {
+	rt := NewRouter()
+	h := new(DefaultHandler)
+	f := func(http.ResponseWriter, *http.Request, URIVars) int { return http.StatusOK }
+
+	routes := []string{"/a/{x}/b", "/a/{y}/c", "/a/{z:int}/c", "/a/{w}/{v}"}
+	for _, uri := range routes {
+		if err := rt.AppendRoute(uri, func() Handler { return h }); err != nil {
+			t.Fatal("Cannot append a valid route", uri, err)
+		}
+	}
+
+	err := rt.AppendRouteFunc("POST", "/a/{k}/b", f)
+	if err != ErrDifferentWildcards {
+		t.Fatal("Appending a function with different wildcard names", err)
+	}
+
+	err = rt.AppendRouteFunc("POST", "/a/{x}/b", f)
+	if err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	data := []struct {
+		uri      string
+		expected URIVars
+	}{
+		{uri: "/a/1/b", expected: URIVars{"x": "1"}},
+		{uri: "/a/one/c", expected: URIVars{"y": "one"}},
+		{uri: "/a/1/c", expected: URIVars{"z": "1"}},
+		{uri: "/a/1/d", expected: URIVars{"w": "1", "v": "d"}},
+	}
+
+	for i, item := range data {
+		route, err := rt.Match(item.uri)
+		if err != nil {
+			t.Errorf("Item %d: cannot find a valid route “%s”; %s", i, item.uri, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(route.URIVars, item.expected) {
+			t.Errorf("Item %d: wrong URI variables. Expecting “%v”; found “%v”", i, item.expected, route.URIVars)
+		}
+	}
+}
+
+func TestURL(t *testing.T) {
+	rt := NewRouter()
+	h := new(DefaultHandler)
+	f := func(http.ResponseWriter, *http.Request, URIVars) int { return http.StatusOK }
+
+	if err := rt.AppendRoute("/user/{id:int}/machine/{ip}", func() Handler { return h }, Name("machine")); err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	if err := rt.AppendRouteFunc("GET", "/files/{path*}", f, Name("files")); err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	if err := rt.AppendRoute("/other", func() Handler { return h }, Name("files")); err != ErrNameAlreadyExists {
+		t.Fatal("Appending a route with a name in use", err)
+	}
+
+	data := []struct {
+		name        string
+		vars        URIVars
+		expected    string
+		expectedErr error
+	}{
+		{name: "machine", vars: URIVars{"id": "17", "ip": "10.0.0.1"}, expected: "/user/17/machine/10.0.0.1"},
+		{name: "machine", vars: URIVars{"id": "17", "ip": "a b/c"}, expected: "/user/17/machine/a%20b%2Fc"},
+		{name: "machine", vars: URIVars{"id": "17"}, expectedErr: ErrMissingURIVar},
+		{name: "machine", vars: URIVars{"id": "x", "ip": "10.0.0.1"}, expectedErr: ErrInvalidURIVar},
+		{name: "files", vars: URIVars{"path": "a/b c/d.txt"}, expected: "/files/a/b%20c/d.txt"},
+		{name: "unknown", expectedErr: ErrUnknownName},
+	}
+
+	for i, item := range data {
+		u, err := rt.URL(item.name, item.vars)
+		if err != item.expectedErr {
+			t.Errorf("Item %d: unexpected error. Expecting “%v”; found “%v”", i, item.expectedErr, err)
+		}
+
+		if u != item.expected {
+			t.Errorf("Item %d: wrong URL. Expecting “%s”; found “%s”", i, item.expected, u)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	rt := NewRouter()
+	f := func(http.ResponseWriter, *http.Request, URIVars) int { return http.StatusOK }
+
+	if err := rt.AppendRoute("/users/{id:int}/", func() Handler { return new(mockGetHandler) }); err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	if err := rt.AppendRouteFunc("DELETE", "/users//me", f); err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	if err := rt.AppendRouteFunc("GET", "/files/{path*}", f); err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	if err := rt.AppendRouteFunc("GET", "/users/{name}", f); err != nil {
+		t.Fatal("Cannot append a valid route", err)
+	}
+
+	var patterns, methods []string
+	err := rt.Walk(func(pattern string, c Constructor, m []string) error {
+		patterns = append(patterns, pattern)
+		methods = append(methods, strings.Join(m, ","))
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal("Unexpected error walking the routes", err)
+	}
+
+	expected := []string{"/files/{path*}", "/users/me", "/users/{id:int}", "/users/{name}"}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Errorf("Wrong patterns. Expecting “%v”; found “%v”", expected, patterns)
+	}
+
+	expected = []string{"GET,HEAD,OPTIONS", "DELETE,OPTIONS", "GET,HEAD,OPTIONS", "GET,HEAD,OPTIONS"}
+	if !reflect.DeepEqual(methods, expected) {
+		t.Errorf("Wrong methods. Expecting “%v”; found “%v”", expected, methods)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = rt.Walk(func(string, Constructor, []string) error {
+		calls++
+		return stop
+	})
+
+	if err != stop || calls != 1 {
+		t.Errorf("Walk didn't stop at the first error: %v, %d calls", err, calls)
+	}
+}
+
+func TestRemoveRoute(t *testing.T) {
+	rt := NewRouter()
+	h := new(DefaultHandler)
+
+	for _, uri := range []string{"/a/{x}/b", "/a/{x}/b/c"} {
+		if err := rt.AppendRoute(uri, func() Handler { return h }, Name(uri)); err != nil {
+			t.Fatal("Cannot append a valid route", uri, err)
+		}
+	}
+
+	clone := rt.Clone()
+
+	if err := rt.RemoveRoute("/a/{y}/b/c"); err != nil {
+		t.Fatal("Cannot remove a valid route", err)
+	}
+
+	if err := rt.RemoveRoute("/a/{x}/b/c"); err != ErrRouteNotFound {
+		t.Fatal("Removing the same route twice", err)
+	}
+
+	if _, err := rt.Match("/a/1/b/c"); err != ErrRouteNotFound {
+		t.Error("Matching a removed route", err)
+	}
+
+	if _, err := rt.URL("/a/{x}/b/c", URIVars{"x": "1"}); err != ErrUnknownName {
+		t.Error("Building the URL of a removed route", err)
+	}
+
+	if _, err := rt.Match("/a/1/b"); err != nil {
+		t.Error("Cannot find a valid route", err)
+	}
+
+	if err := rt.RemoveRoute("/a/{x}/b"); err != nil {
+		t.Fatal("Cannot remove a valid route", err)
+	}
+
+	if !rt.root.isEmpty() {
+		t.Error("Nodes without routes were not discarded")
+	}
+
+	// The clone must not be affected
+
+	for _, uri := range []string{"/a/1/b", "/a/1/b/c"} {
+		if _, err := clone.Match(uri); err != nil {
+			t.Error("Cannot find a valid route in the clone", uri, err)
+		}
+	}
+}